@@ -1,20 +1,34 @@
 package core
 
-import "github.com/djskncxm/NewDuckSpider/pkg/httpc"
+import (
+	"sync"
 
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+)
+
+// Scheduler 请求队列。初始请求经有界的 queue 入队，队列满时阻塞以限制内存；
+// worker 产生的请求从不阻塞，queue 满时放入无界的 overflow，worker 优先取 overflow，
+// 因此初始请求占满 queue 时 worker 仍能入队并继续消费，不会互相等待而死锁
 type Scheduler struct {
-	queue chan *httpc.Request
+	queue    chan *httpc.Request
+	mu       sync.Mutex
+	overflow []*httpc.Request
+	wake     chan struct{} // overflow 有新请求时唤醒阻塞在 queue 上的 worker
 }
 
 // NewScheduler 创建指定缓冲大小的调度器
 func NewScheduler(bufferSize int) *Scheduler {
 	return &Scheduler{
 		queue: make(chan *httpc.Request, bufferSize),
+		wake:  make(chan struct{}, 1),
 	}
 }
 
 // NextRequest 非阻塞获取请求
 func (s *Scheduler) NextRequest() *httpc.Request {
+	if req := s.popOverflow(); req != nil {
+		return req
+	}
 	select {
 	case req := <-s.queue:
 		return req
@@ -23,23 +37,72 @@ func (s *Scheduler) NextRequest() *httpc.Request {
 	}
 }
 
-// EnqueueRequest 入队请求（可能阻塞直到有空闲缓冲）
+// EnqueueRequest 入队请求（可能阻塞直到有空闲缓冲），用于初始请求
 func (s *Scheduler) EnqueueRequest(req *httpc.Request) {
 	s.queue <- req
 }
 
+// PushRequest 入队请求，从不阻塞：queue 满时放入 overflow。
+// worker 及回调产生的请求必须使用它，否则 queue 被初始请求占满时所有 worker 会阻塞在入队上
+func (s *Scheduler) PushRequest(req *httpc.Request) {
+	select {
+	case s.queue <- req:
+		return
+	default:
+	}
+	s.mu.Lock()
+	s.overflow = append(s.overflow, req)
+	s.mu.Unlock()
+	s.signal()
+}
+
+// popOverflow 取出 overflow 中最早的请求，取出后仍有剩余时继续唤醒其他 worker
+func (s *Scheduler) popOverflow() *httpc.Request {
+	s.mu.Lock()
+	if len(s.overflow) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	req := s.overflow[0]
+	s.overflow[0] = nil
+	s.overflow = s.overflow[1:]
+	remaining := len(s.overflow)
+	s.mu.Unlock()
+	if remaining > 0 {
+		s.signal()
+	}
+	return req
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
 // Empty 判断队列是否为空（快照，非精确）
 func (s *Scheduler) Empty() bool {
-	return len(s.queue) == 0
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue) == 0 && len(s.overflow) == 0
 }
 
 // NextRequestBlocking 阻塞获取请求，直到有请求或者队列关闭
 func (s *Scheduler) NextRequestBlocking() (*httpc.Request, bool) {
-	req, ok := <-s.queue
-	return req, ok
+	for {
+		if req := s.popOverflow(); req != nil {
+			return req, true
+		}
+		select {
+		case req, ok := <-s.queue:
+			return req, ok
+		case <-s.wake:
+		}
+	}
 }
 
-// CloseScheduler 关闭队列，通知 worker 可以退出
+// CloseScheduler 关闭队列，通知 worker 可以退出。调用方需保证不再有请求入队
 func (s *Scheduler) CloseScheduler() {
 	close(s.queue)
 }
@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"iter"
+	"slices"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -17,7 +19,7 @@ import (
 )
 
 type Engine struct {
-	spider            spider.Spider
+	spider            spider.SpiderIns
 	download          download.Download
 	scheduler         *Scheduler
 	Config            *setting.SettingsManager
@@ -25,44 +27,96 @@ type Engine struct {
 	Logger            *logger.Logger
 	MiddlewareManager *middleware.MiddlewareManager
 	activeReqs        atomic.Int64
+	startsDone        atomic.Bool   // 初始请求已全部入队
+	delay             time.Duration // 每个 worker 相邻两次下载之间的等待时间
+	slots             chan struct{} // 下载名额，worker 与 Fetch 共用，同时进行的下载不超过 Spider.Worker
+	fetchMu           sync.Mutex    // 保护 lastFetch
+	lastFetch         time.Time     // Fetch 上一次下载的时间
 }
 
-func InitEngine(spider spider.Spider, Config *setting.SettingsManager, LogConfig logger.LogConfig, PipelineConfig item.PipelineConfig) Engine {
+// InitEngine 创建引擎，sp 可以是任何实现了 SpiderIns 的类型，
+// 并可选实现 spider 包中的 Opener、Closer、LoggerSetter 等接口
+func InitEngine(sp spider.SpiderIns, Config *setting.SettingsManager, LogConfig logger.LogConfig, PipelineConfig item.PipelineConfig) Engine {
 	logger, err := logger.NewLogger(&LogConfig)
 	if err != nil {
 		panic(fmt.Errorf("日志系统初始化错误: %w", err))
 	}
 
 	mi := middleware.NewMiddlewareManager()
+	for _, group := range Config.GetList("Middleware.DisabledGroups", nil) {
+		mi.DisableGroup(group)
+	}
 
-	spider.Logger = logger
+	switch s := sp.(type) {
+	case spider.Spider:
+		// 以值注册的 Spider 没有 SetLogger 方法，直接设置引擎持有的副本，Start 等方法在该副本上执行
+		s.Logger = logger
+		sp = s
+	case spider.LoggerSetter:
+		s.SetLogger(logger)
+	}
 
 	return Engine{
-		spider:            spider,
-		download:          download.InitDownload(logger, mi),
-		scheduler:         NewScheduler(1000),
+		spider:            sp,
+		download:          download.InitDownload(logger, mi, downloadConfig(Config)),
+		scheduler:         NewScheduler(Config.GetInt("Scheduler.QueueSize", 1000)),
 		Config:            Config,
 		ItemPipeline:      item.NewItemPipeline(PipelineConfig, logger),
 		Logger:            logger,
 		MiddlewareManager: mi,
+		delay:             Config.GetDuration("Spider.Delay", 0),
+		slots:             make(chan struct{}, max(Config.GetInt("Spider.Worker", 3), 1)),
+	}
+}
+
+// downloadConfig 从配置中读取下载器配置
+func downloadConfig(Config *setting.SettingsManager) download.Config {
+	userAgent, _ := Config.GetString("Headers.UserAgent")
+	proxy, _ := Config.GetString("Spider.Proxy")
+	return download.Config{
+		Timeout:             Config.GetDuration("Spider.Timeout", 15*time.Second),
+		Retry:               Config.GetInt("Spider.Retry", 0),
+		Proxy:               proxy,
+		UserAgent:           userAgent,
+		DefaultHeaders:      Config.GetMap("Downloader.DefaultHeaders", nil),
+		MaxIdleConns:        Config.GetInt("Downloader.MaxIdleConns", 1000),
+		MaxIdleConnsPerHost: Config.GetInt("Downloader.MaxIdleConnsPerHost", 1000),
 	}
 }
 
-func (e *Engine) StartSpider() {
+// StartSpider 运行爬虫直到所有请求处理完毕。爬虫实现了 Opener 时先调用 Open，
+// 失败时不启动并返回错误；实现了 Closer 时在项目管道处理完全部项目后调用 Close
+func (e *Engine) StartSpider() error {
 	e.Logger.Debug("框架启动")
+	if op, ok := e.spider.(spider.Opener); ok {
+		if err := op.Open(); err != nil {
+			e.Logger.Errorf("爬虫 %s 启动失败: %v", e.spider.Name(), err)
+			e.closeSpider(spider.CloseOpenFailed)
+			return fmt.Errorf("爬虫 %s 启动失败: %w", e.spider.Name(), err)
+		}
+	}
+
 	var concurrency int = e.Config.GetInt("Spider.Worker", 3)
 	e.Logger.Debug("并发数 -> " + strconv.Itoa(concurrency))
 
 	go e.ItemPipeline.ProcessNext()
 
-	for _, req := range e.spider.Start() {
-		e.EnRequest(req)
-	}
+	// 初始请求按需产生，队列满时入队阻塞，直到 worker 取走请求腾出空位；
+	// worker 产生的请求经 EnRequest 入队，不会被初始请求阻塞
+	go func() {
+		for req := range e.startRequests() {
+			e.activeReqs.Add(1)
+			e.Logger.Stats.AddInt("Request 入队", 1)
+			e.scheduler.EnqueueRequest(req)
+		}
+		e.startsDone.Store(true)
+		e.Logger.Debug("初始请求已全部入队")
+	}()
 
-	// 监控协程：当所有请求处理完毕、队列为空时，关闭 scheduler 通知 worker 退出
+	// 监控协程：当初始请求全部入队、所有请求处理完毕且队列为空时，关闭 scheduler 通知 worker 退出
 	go func() {
 		for {
-			if e.activeReqs.Load() == 0 && e.scheduler.Empty() {
+			if e.startsDone.Load() && e.activeReqs.Load() == 0 && e.scheduler.Empty() {
 				e.scheduler.CloseScheduler()
 				return
 			}
@@ -79,11 +133,35 @@ func (e *Engine) StartSpider() {
 		}()
 	}
 	wg.Wait()
+	// Close 阻塞到管道中的项目全部处理完毕，之后处理器不会再使用爬虫的资源
 	e.ItemPipeline.Close()
+	e.closeSpider(spider.CloseFinished)
 	e.Logger.Debug("框架关闭")
+	return nil
+}
+
+// closeSpider 调用爬虫的 Close 并记录关闭原因
+func (e *Engine) closeSpider(reason string) {
+	e.Logger.Stats.Set("Spider 关闭原因", reason)
+	cl, ok := e.spider.(spider.Closer)
+	if !ok {
+		return
+	}
+	if err := cl.Close(reason); err != nil {
+		e.Logger.Errorf("爬虫 %s 关闭失败: %v", e.spider.Name(), err)
+	}
+}
+
+// startRequests 返回爬虫的初始请求序列，实现了 StartStreamer 的爬虫按需产生
+func (e *Engine) startRequests() iter.Seq[*httpc.Request] {
+	if s, ok := e.spider.(spider.StartStreamer); ok {
+		return s.StartRequests()
+	}
+	return slices.Values(e.spider.Start())
 }
+
 func (e *Engine) worker() {
-	for {
+	for first := true; ; first = false {
 		req, ok := e.scheduler.NextRequestBlocking()
 		if !ok {
 			// channel 已关闭，无更多任务，正常退出
@@ -91,10 +169,19 @@ func (e *Engine) worker() {
 		}
 
 		e.Logger.Stats.AddInt("Request 出队", 1)
+		if e.delay > 0 && !first {
+			time.Sleep(e.delay)
+		}
 
 		resp := e.fetch(req)
 
-		if req.Callback != nil {
+		switch {
+		case req.Stream != nil:
+			// 流式回调产生的请求与 Item 立即入队，回调返回后 emitter 失效
+			em := &engineEmitter{e: e}
+			req.Stream(resp, em)
+			em.invalidate()
+		case req.Callback != nil:
 			parseResult := req.Callback(resp)
 			if parseResult != nil {
 				// 新请求入队
@@ -103,8 +190,7 @@ func (e *Engine) worker() {
 				}
 				// Items 入队
 				for _, it := range parseResult.Items {
-					it.Metadata.SpiderName = e.spider.Name()
-					e.EnItem(it)
+					e.emitItem(it)
 				}
 			}
 		}
@@ -113,17 +199,82 @@ func (e *Engine) worker() {
 	}
 }
 
+// emitItem 标记 Item 所属的爬虫后入队
+func (e *Engine) emitItem(it *item.StrictItem) {
+	it.Metadata.SpiderName = e.spider.Name()
+	e.EnItem(it)
+}
+
+// engineEmitter 将流式回调的结果直接交给引擎。回调返回后 emitter 失效，
+// 之后（例如在回调启动的 goroutine 中）产生的结果被丢弃并记录错误：
+// 此时请求已不计入活跃数，调度器可能已经关闭
+type engineEmitter struct {
+	e    *Engine
+	mu   sync.Mutex
+	done bool
+}
+
+func (em *engineEmitter) Request(req *httpc.Request) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	if em.done {
+		em.e.Logger.Stats.AddInt("Request 回调返回后丢弃", 1)
+		em.e.Logger.Errorf("流式回调返回后产生的请求被丢弃: %s", req.URL)
+		return
+	}
+	em.e.EnRequest(req)
+}
+
+func (em *engineEmitter) Item(it *item.StrictItem) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	if em.done {
+		em.e.Logger.Stats.AddInt("Item 回调返回后丢弃", 1)
+		em.e.Logger.Errorf("流式回调返回后产生的 Item 被丢弃")
+		return
+	}
+	em.e.emitItem(it)
+}
+
+// invalidate 使 emitter 失效，等待正在进行的入队完成后返回
+func (em *engineEmitter) invalidate() {
+	em.mu.Lock()
+	em.done = true
+	em.mu.Unlock()
+}
+
 func (e *Engine) EnItem(item *item.StrictItem) {
 	e.Logger.Stats.AddInt("Item 入队", 1)
 	e.ItemPipeline.EnqueueItem(item)
 }
 
+// fetch 占用一个下载名额下载请求
 func (e *Engine) fetch(request *httpc.Request) *httpc.Response {
+	e.slots <- struct{}{}
+	defer func() { <-e.slots }()
 	return e.download.Fetch(request)
 }
 
+// Fetch 同步下载请求，供媒体管道等在项目管道中下载的组件使用。
+// 请求经过同一个下载器和中间件，与 worker 共用下载名额（同时下载数不超过 Spider.Worker），
+// 并像一个额外的 worker 一样在相邻两次下载之间等待 Spider.Delay。
+// 项目管道在 worker 退出后仍可能在处理项目，所以请求不进入调度队列
+func (e *Engine) Fetch(request *httpc.Request) *httpc.Response {
+	e.Logger.Stats.AddInt("Request 直接下载", 1)
+	if e.delay > 0 {
+		e.fetchMu.Lock()
+		if wait := time.Until(e.lastFetch.Add(e.delay)); wait > 0 {
+			time.Sleep(wait)
+		}
+		e.lastFetch = time.Now()
+		e.fetchMu.Unlock()
+	}
+	return e.fetch(request)
+}
+
+// EnRequest 请求入队，从不阻塞
 func (e *Engine) EnRequest(request *httpc.Request) {
 	e.activeReqs.Add(1)
 	e.Logger.Stats.AddInt("Request 入队", 1)
-	e.scheduler.EnqueueRequest(request)
+	e.scheduler.PushRequest(request)
 }
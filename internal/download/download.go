@@ -1,6 +1,7 @@
 package download
 
 import (
+	"bytes"
 	// "fmt"
 	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
 	"github.com/djskncxm/NewDuckSpider/pkg/logger"
@@ -10,6 +11,7 @@ import (
 	// "github.com/enetx/surf"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 )
 
@@ -18,6 +20,19 @@ type Download struct {
 	MiddlewareManager *middleware.MiddlewareManager
 	Logger            *logger.Logger
 	mu                sync.Mutex
+	client            *http.Client
+	config            Config
+}
+
+// Config 下载器配置，零值字段使用默认值
+type Config struct {
+	Timeout             time.Duration     // 单个请求的超时时间，默认 15 秒
+	Retry               int               // 网络错误时的重试次数
+	Proxy               string            // 代理地址
+	UserAgent           string            // 请求未设置 User-Agent 时使用
+	DefaultHeaders      map[string]string // 请求未设置时添加的请求头
+	MaxIdleConns        int               // 默认 1000
+	MaxIdleConnsPerHost int               // 默认 1000
 }
 
 var client = &http.Client{
@@ -28,30 +43,94 @@ var client = &http.Client{
 	Timeout: 15 * time.Second,
 }
 
-func InitDownload(Loggger *logger.Logger, MiddlewareManager *middleware.MiddlewareManager) Download {
+// InitDownload 创建下载器，不传 config 时使用共享的默认客户端
+func InitDownload(Loggger *logger.Logger, MiddlewareManager *middleware.MiddlewareManager, config ...Config) Download {
+	var cfg Config
+	c := client
+	if len(config) > 0 {
+		cfg = config[0]
+		c = newClient(cfg, Loggger)
+	}
 	return Download{
 		Logger:            Loggger,
 		MiddlewareManager: MiddlewareManager,
+		client:            c,
+		config:            cfg,
 	}
 }
 
-func (d *Download) Fetch(request *httpc.Request) *httpc.Response {
-	d.MiddlewareManager.ProcessRequest(request)
-	// surfClient := surf.NewClient().Builder().Impersonate().Linux().Chrome().Session().Build().Unwrap()
-	// stdClient := surfClient.Std()
-	// resp, err := stdClient.Get(request.URL)
-	// resp, err := client.Get(request.URL)
+// newClient 按配置创建 HTTP 客户端，代理地址无效时记录错误并直连
+func newClient(config Config, log *logger.Logger) *http.Client {
+	transport := &http.Transport{
+		MaxIdleConns:        1000,
+		MaxIdleConnsPerHost: 1000,
+	}
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			log.Errorf("代理地址无效 %q: %v", config.Proxy, err)
+		} else {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	timeout := 15 * time.Second
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
 
-	req, err := http.NewRequest(request.Method, request.URL, nil)
+// newRequest 构造 HTTP 请求，默认请求头先于请求自身的请求头设置
+func (d *Download) newRequest(request *httpc.Request) (*http.Request, error) {
+	var reqBody io.Reader
+	if len(request.Body) > 0 {
+		reqBody = bytes.NewReader(request.Body)
+	}
+	req, err := http.NewRequest(request.Method, request.URL, reqBody)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	for k, v := range d.config.DefaultHeaders {
+		req.Header.Set(k, v)
+	}
+	if d.config.UserAgent != "" {
+		req.Header.Set("User-Agent", d.config.UserAgent)
 	}
 	for k, v := range request.Headers {
 		req.Header.Set(k, v)
 	}
+	return req, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
+func (d *Download) Fetch(request *httpc.Request) *httpc.Response {
+	d.MiddlewareManager.ProcessRequest(request)
+	// surfClient := surf.NewClient().Builder().Impersonate().Linux().Chrome().Session().Build().Unwrap()
+	// stdClient := surfClient.Std()
+	// resp, err := stdClient.Get(request.URL)
+	// resp, err := client.Get(request.URL)
+
+	var resp *http.Response
+	for attempt := 0; ; attempt++ {
+		req, err := d.newRequest(request)
+		if err != nil {
+			d.Logger.Stats.AddInt("Request 构造失败", 1)
+			d.MiddlewareManager.ProcessException(err)
+			return nil
+		}
+		resp, err = d.client.Do(req)
+		if err == nil {
+			break
+		}
+		if attempt < d.config.Retry {
+			d.Logger.Stats.AddInt("Request 重试", 1)
+			continue
+		}
 		d.Logger.Stats.AddInt("Request 请求失败", 1)
 		d.MiddlewareManager.ProcessException(err)
 		return nil
@@ -0,0 +1,182 @@
+package setting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnv 指定配置文件路径的环境变量
+const ConfigEnv = "DUCKSPIDER_CONFIG"
+
+// configNames 在每一级目录中依次查找的配置文件
+var configNames = []string{
+	"config/config.yaml", "config/config.yml", "config/config.toml", "config/config.json",
+	"config.yaml", "config.yml", "config.toml", "config.json",
+}
+
+// rootMarkers 项目根目录的标志，向上查找配置文件时到此为止
+var rootMarkers = []string{"go.mod", ".git"}
+
+// ConfigFlag 从命令行参数中取出 "--config PATH" 或 "--config=PATH" 指定的配置文件路径，
+// 未指定时返回空串
+func ConfigFlag(args []string) (string, error) {
+	for i, arg := range args {
+		switch {
+		case arg == "--config":
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("setting: --config 缺少路径")
+			}
+			return args[i+1], nil
+		case strings.HasPrefix(arg, "--config="):
+			return strings.TrimPrefix(arg, "--config="), nil
+		}
+	}
+	return "", nil
+}
+
+// FindConfig 查找配置文件：依次使用 path（如 --config 参数）、环境变量 DUCKSPIDER_CONFIG，
+// 最后从当前目录向上逐级查找 configNames，直到项目根目录（含 go.mod 或 .git）。
+// 显式指定的文件不存在时返回错误，查找不到时返回空串，此时使用内置默认值
+func FindConfig(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(ConfigEnv)
+	}
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range configNames {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+		if isProjectRoot(dir) {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func isProjectRoot(dir string) bool {
+	for _, marker := range rootMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFile 以指定优先级加载配置文件，按扩展名解析 YAML、TOML 或 JSON（其他扩展名按 YAML 解析）。
+// 字符串值中的 ${NAME} 与 ${NAME:-默认值} 替换为环境变量
+func (sm *SettingsManager) LoadFile(path string, priority int) error {
+	raw, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sm.LoadMap(raw, priority)
+	sm.mu.Lock()
+	sm.file = path
+	sm.mu.Unlock()
+	return nil
+}
+
+// File 返回加载的配置文件路径，未加载文件时返回空串
+func (sm *SettingsManager) File() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.file
+}
+
+// ReadFile 读取并解析配置文件，返回替换过环境变量的嵌套 map
+func ReadFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&raw)
+	default:
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("setting: 解析配置文件 %s 失败: %w", path, err)
+	}
+
+	var missing []string
+	expandMap(raw, &missing)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("setting: 配置文件 %s 引用了未设置的环境变量 %s", path, strings.Join(missing, ", "))
+	}
+	return raw, nil
+}
+
+// envPattern 匹配 ${NAME} 与 ${NAME:-默认值}
+var envPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandMap 原地替换字符串值中的环境变量，未设置且没有默认值的变量名追加到 missing
+func expandMap(m map[string]any, missing *[]string) {
+	for k, v := range m {
+		m[k] = expandValue(v, missing)
+	}
+}
+
+func expandValue(v any, missing *[]string) any {
+	switch v := v.(type) {
+	case string:
+		return expandEnv(v, missing)
+	case map[string]any:
+		expandMap(v, missing)
+	case []any:
+		for i, e := range v {
+			v[i] = expandValue(e, missing)
+		}
+	case []map[string]any:
+		for _, e := range v {
+			expandMap(e, missing)
+		}
+	}
+	return v
+}
+
+func expandEnv(s string, missing *[]string) string {
+	return envPattern.ReplaceAllStringFunc(s, func(match string) string {
+		sub := envPattern.FindStringSubmatch(match)
+		value, ok := os.LookupEnv(sub[1])
+		if ok && (value != "" || sub[2] == "") {
+			return value
+		}
+		if sub[2] != "" {
+			return sub[3]
+		}
+		*missing = append(*missing, sub[1])
+		return ""
+	})
+}
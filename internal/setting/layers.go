@@ -0,0 +1,163 @@
+package setting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// 配置来源的优先级，数值大的覆盖数值小的
+const (
+	PriorityDefault = 0  // 内置默认值
+	PriorityProject = 10 // 项目配置文件
+	PrioritySpider  = 20 // 爬虫的 CustomSettings
+	PriorityEnv     = 30 // 环境变量
+	PriorityCmdline = 40 // 命令行 -s KEY=VALUE
+)
+
+// EnvPrefix 配置环境变量的前缀，DUCK_SPIDER__WORKER=8 对应 Spider.Worker
+const EnvPrefix = "DUCK_"
+
+// PriorityName 返回优先级对应的来源名称
+func PriorityName(priority int) string {
+	switch priority {
+	case PriorityDefault:
+		return "default"
+	case PriorityProject:
+		return "project"
+	case PrioritySpider:
+		return "spider"
+	case PriorityEnv:
+		return "env"
+	case PriorityCmdline:
+		return "cmdline"
+	}
+	return fmt.Sprintf("priority(%d)", priority)
+}
+
+// LoadDefaults 以默认优先级加载全部登记配置项的默认值
+func (sm *SettingsManager) LoadDefaults() {
+	for _, def := range Definitions() {
+		sm.Set(def.Key, def.Default, PriorityDefault)
+	}
+}
+
+// LoadMap 加载嵌套的配置（如 YAML 解析得到的 map），嵌套的键以 "." 连接，
+// 列表以 "," 连接，登记为 KindMap 的键保存为 JSON 对象。只设置文件中出现的键，未出现的键保留默认值
+func (sm *SettingsManager) LoadMap(values map[string]any, priority int) {
+	sm.loadMap(values, "", priority)
+}
+
+func (sm *SettingsManager) loadMap(values map[string]any, prefix string, priority int) {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if def, ok := Lookup(key); ok && def.Kind == KindMap {
+				data, err := json.Marshal(v)
+				if err == nil {
+					sm.Set(key, string(data), priority)
+					continue
+				}
+			}
+			sm.loadMap(v, key, priority)
+		case []any:
+			parts := make([]string, len(v))
+			for i, e := range v {
+				parts[i] = fmt.Sprint(e)
+			}
+			sm.Set(key, strings.Join(parts, ","), priority)
+		case nil:
+			sm.Set(key, "", priority)
+		default:
+			sm.Set(key, fmt.Sprint(v), priority)
+		}
+	}
+}
+
+// LoadEnv 从环境变量（格式同 os.Environ）中加载以 prefix 开头的配置，
+// 段之间以双下划线分隔：DUCK_SPIDER__WORKER=8 → Spider.Worker。
+// 已知的键不区分大小写匹配，未知的键按首字母大写转换
+func (sm *SettingsManager) LoadEnv(prefix string, environ []string) {
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(name, prefix), "__")
+		for i, p := range parts {
+			if p == "" {
+				parts = nil
+				break
+			}
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		}
+		if len(parts) < 2 {
+			continue
+		}
+		sm.Set(strings.Join(parts, "."), value, PriorityEnv)
+	}
+}
+
+// LoadArgs 从命令行参数中加载 "-s KEY=VALUE"、"--set KEY=VALUE"、"-s=KEY=VALUE" 形式的配置，
+// 返回其余参数
+func (sm *SettingsManager) LoadArgs(args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		var pair string
+		switch {
+		case arg == "-s" || arg == "--set":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("setting: %s 缺少 KEY=VALUE", arg)
+			}
+			i++
+			pair = args[i]
+		case strings.HasPrefix(arg, "-s="):
+			pair = strings.TrimPrefix(arg, "-s=")
+		case strings.HasPrefix(arg, "--set="):
+			pair = strings.TrimPrefix(arg, "--set=")
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("setting: 无效的配置 %q，应为 KEY=VALUE", pair)
+		}
+		sm.Set(strings.TrimSpace(key), value, PriorityCmdline)
+	}
+	return rest, nil
+}
+
+// Dump 按键排序输出生效的配置及其来源
+func (sm *SettingsManager) Dump(w io.Writer) error {
+	sm.mu.RLock()
+	keys := make([]string, 0, len(sm.settings))
+	width := 0
+	for k := range sm.settings {
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys)+1)
+	if sm.file != "" {
+		lines = append(lines, fmt.Sprintf("# 配置文件: %s\n", sm.file))
+	}
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%-*s = %-20q [%s]\n", width, k, sm.settings[k], PriorityName(sm.priorities[k])))
+	}
+	sm.mu.RUnlock()
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
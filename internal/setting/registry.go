@@ -0,0 +1,288 @@
+package setting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind 配置值的类型
+type Kind int
+
+const (
+	KindString   Kind = iota
+	KindInt           // 整数
+	KindBool          // true/false/1/0
+	KindFloat         // 浮点数
+	KindDuration      // "1m30s"、"500ms"，不带单位的数字按秒计算
+	KindList          // 逗号分隔的列表
+	KindMap           // "k=v,k2=v2" 或 JSON 对象
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindInt:
+		return "int"
+	case KindBool:
+		return "bool"
+	case KindFloat:
+		return "float"
+	case KindDuration:
+		return "duration"
+	case KindList:
+		return "list"
+	case KindMap:
+		return "map"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Definition 登记的配置项
+type Definition struct {
+	Key     string // 规范写法，如 "Spider.Worker"
+	Kind    Kind
+	Default string // 内置默认值，空串表示未设置
+	Doc     string // 说明
+}
+
+// builtinDefinitions 引擎使用的配置项
+var builtinDefinitions = []Definition{
+	{"Spider.Worker", KindInt, "3", "并发的 worker 数量"},
+	{"Spider.LOGLEVEL", KindString, "debug", "日志级别：debug、info、warn、error"},
+	{"Spider.Timeout", KindDuration, "15", "单个请求的超时时间"},
+	{"Spider.Retry", KindInt, "0", "请求因网络错误失败时的重试次数"},
+	{"Spider.Proxy", KindString, "", "下载使用的代理地址，如 http://127.0.0.1:8080"},
+	{"Spider.Delay", KindDuration, "0", "每个 worker 下载相邻两个请求之间的等待时间"},
+
+	{"Headers.UserAgent", KindString, "", "请求未设置 User-Agent 时使用的默认值"},
+
+	{"Log.LogFormat", KindString, "text", "日志格式：text 或 json"},
+	{"Log.EnableConsole", KindBool, "true", "输出到控制台"},
+	{"Log.ConsoleColor", KindBool, "true", "控制台输出带颜色"},
+	{"Log.EnableFile", KindBool, "true", "输出到日志文件"},
+	{"Log.MaxSize", KindInt, "100", "单个日志文件的最大大小（MB）"},
+	{"Log.MaxBackups", KindInt, "10", "保留的旧日志文件数量"},
+	{"Log.MaxAge", KindInt, "30", "旧日志文件的保留天数"},
+	{"Log.Compress", KindBool, "true", "压缩旧日志文件"},
+
+	{"Scheduler.QueueSize", KindInt, "1000", "请求队列的容量，队列满时入队阻塞"},
+
+	{"Pipeline.MaxSize", KindInt, "1000", "项目管道队列的容量"},
+	{"Pipeline.MaxWaitTime", KindDuration, "5s", "项目管道队列满时入队阻塞的最长等待时间，超时后项目被丢弃，0 表示一直等待"},
+	{"Pipeline.AutoFlushSize", KindInt, "100", "项目管道累计多少个项目后刷新"},
+	{"Pipeline.DeadLetter", KindString, "deadletter/{spider}.jsonl", "处理失败的项目写入的 JSONL 文件，{spider} 替换为爬虫名，空串表示不写入"},
+
+	{"Downloader.MaxIdleConns", KindInt, "1000", "连接池中空闲连接的总数上限"},
+	{"Downloader.MaxIdleConnsPerHost", KindInt, "1000", "连接池中每个主机的空闲连接上限"},
+	{"Downloader.DefaultHeaders", KindMap, "", "请求未设置时添加的请求头"},
+
+	{"Middleware.DisabledGroups", KindList, "", "禁用的中间件分组"},
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Definition) // 小写键 -> 定义
+)
+
+func init() {
+	Register(builtinDefinitions...)
+}
+
+// Register 登记配置项，已登记的键被覆盖。默认值无法按类型解析时 panic，
+// 扩展（中间件、管道等）可以登记自己的配置项，使其参与默认值加载与校验
+func Register(defs ...Definition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, def := range defs {
+		if def.Default != "" {
+			if err := checkKind(def.Kind, def.Default); err != nil {
+				panic(fmt.Sprintf("setting: %s 的默认值 %q 无效: %v", def.Key, def.Default, err))
+			}
+		}
+		registry[strings.ToLower(def.Key)] = def
+	}
+}
+
+// Lookup 查找登记的配置项，不区分大小写
+func Lookup(key string) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	def, ok := registry[strings.ToLower(key)]
+	return def, ok
+}
+
+// Definitions 返回按键排序的全部配置项
+func Definitions() []Definition {
+	registryMu.RLock()
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	registryMu.RUnlock()
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Key < defs[j].Key })
+	return defs
+}
+
+// ErrUnknownKey 配置项未登记，通常是拼写错误
+var ErrUnknownKey = errors.New("未知的配置项")
+
+// SettingError 配置项校验失败
+type SettingError struct {
+	Key    string
+	Value  string
+	Source string // 值的来源，见 PriorityName
+	Err    error
+}
+
+func (e *SettingError) Error() string {
+	return fmt.Sprintf("setting: %s = %q [%s]: %v", e.Key, e.Value, e.Source, e.Err)
+}
+
+func (e *SettingError) Unwrap() error {
+	return e.Err
+}
+
+// Check 按登记表检查单个配置项，未登记时返回包装 ErrUnknownKey 的错误（附带相近的键），
+// 值无法按类型解析（包括数值、布尔与时长为空）时返回解析错误
+func Check(key, value string) error {
+	def, ok := Lookup(key)
+	if !ok {
+		if s := suggest(key); s != "" {
+			return fmt.Errorf("%w，是否为 %s", ErrUnknownKey, s)
+		}
+		return ErrUnknownKey
+	}
+	if value == "" {
+		// 字符串、列表和映射允许为空；数值、布尔与时长的空值无法解析，读取时会静默回退到默认值
+		switch def.Kind {
+		case KindInt, KindBool, KindFloat, KindDuration:
+			return fmt.Errorf("应为 %s: 值为空", def.Kind)
+		}
+		return nil
+	}
+	if err := checkKind(def.Kind, value); err != nil {
+		return fmt.Errorf("应为 %s: %v", def.Kind, err)
+	}
+	return nil
+}
+
+// Validate 按登记表检查全部配置，返回按键排序的问题列表。
+// 未知的键可用 errors.Is(err, ErrUnknownKey) 区分
+func (sm *SettingsManager) Validate() []*SettingError {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	var problems []*SettingError
+	for k, v := range sm.settings {
+		if err := Check(k, v); err != nil {
+			problems = append(problems, &SettingError{Key: k, Value: v, Source: PriorityName(sm.priorities[k]), Err: err})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+	return problems
+}
+
+// checkKind 检查值能否按类型解析
+func checkKind(kind Kind, value string) error {
+	var err error
+	switch kind {
+	case KindInt:
+		_, err = strconv.Atoi(strings.TrimSpace(value))
+	case KindBool:
+		_, err = strconv.ParseBool(strings.TrimSpace(value))
+	case KindFloat:
+		_, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case KindDuration:
+		_, err = parseDuration(value)
+	case KindMap:
+		_, err = parseMap(value)
+	}
+	return err
+}
+
+// parseDuration 解析时长，不带单位的数字按秒计算
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseList 解析逗号分隔的列表，去除空白与空项
+func parseList(value string) []string {
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// parseMap 解析 JSON 对象或 "k=v,k2=v2"
+func parseMap(value string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	m := make(map[string]string)
+	if value == "" {
+		return m, nil
+	}
+	if strings.HasPrefix(value, "{") {
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(value), &raw); err != nil {
+			return nil, err
+		}
+		for k, v := range raw {
+			m[k] = fmt.Sprint(v)
+		}
+		return m, nil
+	}
+	for _, part := range parseList(value) {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q 缺少 =", part)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m, nil
+}
+
+// suggest 返回与 key 编辑距离最近（不超过 2）的已登记键
+func suggest(key string) string {
+	key = strings.ToLower(key)
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	best, bestDist := "", 3
+	for k, def := range registry {
+		if d := editDistance(key, k); d < bestDist || d == bestDist && def.Key < best {
+			best, bestDist = def.Key, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
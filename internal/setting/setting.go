@@ -1,12 +1,16 @@
 package setting
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
+// Setting 配置文件的结构，各字段的说明见登记表（Definitions）
 type Setting struct {
 	Spider struct {
 		Worker   int    `yaml:"Worker"`
@@ -24,31 +28,125 @@ type Setting struct {
 		EnableConsole bool   `yaml:"EnableConsole"`
 		ConsoleColor  bool   `yaml:"ConsoleColor"`
 		EnableFile    bool   `yaml:"EnableFile"`
+		MaxSize       int    `yaml:"MaxSize"`
+		MaxBackups    int    `yaml:"MaxBackups"`
+		MaxAge        int    `yaml:"MaxAge"`
+		Compress      bool   `yaml:"Compress"`
 	} `yaml:"Log"`
+	Scheduler struct {
+		QueueSize int `yaml:"QueueSize"`
+	} `yaml:"Scheduler"`
+	Pipeline struct {
+		MaxSize       int           `yaml:"MaxSize"`
+		MaxWaitTime   time.Duration `yaml:"MaxWaitTime"`
+		AutoFlushSize int           `yaml:"AutoFlushSize"`
+		DeadLetter    string        `yaml:"DeadLetter"`
+	} `yaml:"Pipeline"`
+	Downloader struct {
+		MaxIdleConns        int               `yaml:"MaxIdleConns"`
+		MaxIdleConnsPerHost int               `yaml:"MaxIdleConnsPerHost"`
+		DefaultHeaders      map[string]string `yaml:"DefaultHeaders"`
+	} `yaml:"Downloader"`
+	Middleware struct {
+		DisabledGroups []string `yaml:"DisabledGroups"`
+	} `yaml:"Middleware"`
 }
 
+// SettingsManager 分层配置。每个值带有来源优先级，只有优先级不低于当前值的来源才能覆盖它，
+// 因此各层可以按任意顺序加载。键不区分大小写
 type SettingsManager struct {
-	mu       sync.RWMutex
-	Settings map[string]string
+	mu         sync.RWMutex
+	settings   map[string]string // 只能经 Set 修改，冻结后不可写
+	priorities map[string]int
+	keys       map[string]string // 小写键 -> 首次设置时的键
+	frozen     bool
+	file       string // 加载的配置文件
 }
 
 func NewSettingsManager() *SettingsManager {
 	return &SettingsManager{
-		Settings: make(map[string]string),
+		settings:   make(map[string]string),
+		priorities: make(map[string]int),
+		keys:       make(map[string]string),
 	}
 }
 
+// canonical 返回键的规范写法，调用方需持有锁
+func (sm *SettingsManager) canonical(key string) string {
+	if k, ok := sm.keys[strings.ToLower(key)]; ok {
+		return k
+	}
+	return key
+}
+
 func (sm *SettingsManager) GetString(key string) (string, bool) {
 	sm.mu.RLock()
 	defer sm.mu.RUnlock()
-	val, ok := sm.Settings[key]
+	val, ok := sm.settings[sm.canonical(key)]
 	return val, ok
 }
 
+// All 返回全部配置的副本，修改返回值不影响配置
+func (sm *SettingsManager) All() map[string]string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	all := make(map[string]string, len(sm.settings))
+	for k, v := range sm.settings {
+		all[k] = v
+	}
+	return all
+}
+
+// SetSetting 以项目配置的优先级设置值
 func (sm *SettingsManager) SetSetting(key, value string) {
+	sm.Set(key, value, PriorityProject)
+}
+
+// Set 以指定优先级设置值，priority 低于当前值的优先级时忽略并返回 false。
+// 配置冻结后调用会 panic
+func (sm *SettingsManager) Set(key, value string, priority int) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.frozen {
+		panic(fmt.Sprintf("setting: 配置已冻结，不能修改 %s", key))
+	}
+	key = sm.canonical(key)
+	if old, ok := sm.priorities[key]; ok && priority < old {
+		return false
+	}
+	sm.keys[strings.ToLower(key)] = key
+	sm.settings[key] = value
+	sm.priorities[key] = priority
+	return true
+}
+
+// SetMany 以同一优先级设置多个值
+func (sm *SettingsManager) SetMany(values map[string]string, priority int) {
+	for k, v := range values {
+		sm.Set(k, v, priority)
+	}
+}
+
+// Priority 返回键当前值的优先级
+func (sm *SettingsManager) Priority(key string) (int, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	p, ok := sm.priorities[sm.canonical(key)]
+	return p, ok
+}
+
+// Freeze 冻结配置，之后的修改会 panic。每个爬虫持有自己的冻结副本
+func (sm *SettingsManager) Freeze() {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
-	sm.Settings[key] = value
+	sm.frozen = true
+}
+
+// Frozen 报告配置是否已冻结
+func (sm *SettingsManager) Frozen() bool {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.frozen
 }
 
 func (sm *SettingsManager) GetInt(key string, defaultVal int) int {
@@ -75,6 +173,73 @@ func (sm *SettingsManager) GetBool(key string, defaultVal bool) bool {
 	return boolVal
 }
 
+// GetFloat 获取浮点数，未设置或无法解析时返回 defaultVal
+func (sm *SettingsManager) GetFloat(key string, defaultVal float64) float64 {
+	val, ok := sm.GetString(key)
+	if !ok {
+		return defaultVal
+	}
+	floatVal, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		return defaultVal
+	}
+	return floatVal
+}
+
+// GetDuration 获取时长（"1m30s"、"500ms"，不带单位的数字按秒计算），
+// 未设置、为空或无法解析时返回 defaultVal
+func (sm *SettingsManager) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	val, ok := sm.GetString(key)
+	if !ok || strings.TrimSpace(val) == "" {
+		return defaultVal
+	}
+	d, err := parseDuration(val)
+	if err != nil {
+		return defaultVal
+	}
+	return d
+}
+
+// GetList 获取逗号分隔的列表，去除空白与空项，未设置时返回 defaultVal
+func (sm *SettingsManager) GetList(key string, defaultVal []string) []string {
+	val, ok := sm.GetString(key)
+	if !ok {
+		return defaultVal
+	}
+	return parseList(val)
+}
+
+// GetMap 获取键值表（JSON 对象或 "k=v,k2=v2"），未设置或无法解析时返回 defaultVal
+func (sm *SettingsManager) GetMap(key string, defaultVal map[string]string) map[string]string {
+	val, ok := sm.GetString(key)
+	if !ok {
+		return defaultVal
+	}
+	m, err := parseMap(val)
+	if err != nil {
+		return defaultVal
+	}
+	return m
+}
+
+// Clone 返回配置的独立副本（保留优先级，不冻结），修改副本不影响原配置
+func (sm *SettingsManager) Clone() *SettingsManager {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	clone := NewSettingsManager()
+	for k, v := range sm.settings {
+		clone.settings[k] = v
+	}
+	for k, p := range sm.priorities {
+		clone.priorities[k] = p
+	}
+	for k, v := range sm.keys {
+		clone.keys[k] = v
+	}
+	clone.file = sm.file
+	return clone
+}
+
 // 递归加载结构体到 map[string]string
 func (sm *SettingsManager) LoadFromSetting(s interface{}) {
 	sm.loadStruct(reflect.ValueOf(s), "")
@@ -95,6 +260,11 @@ func (sm *SettingsManager) loadStruct(v reflect.Value, prefix string) {
 			key = prefix + "." + key
 		}
 
+		if d, ok := val.Interface().(time.Duration); ok {
+			sm.SetSetting(key, d.String())
+			continue
+		}
+
 		switch val.Kind() {
 		case reflect.Struct:
 			sm.loadStruct(val, key) // 递归
@@ -106,6 +276,22 @@ func (sm *SettingsManager) loadStruct(v reflect.Value, prefix string) {
 			sm.SetSetting(key, strconv.FormatInt(val.Int(), 10))
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			sm.SetSetting(key, strconv.FormatUint(val.Uint(), 10))
+		case reflect.Slice:
+			parts := make([]string, val.Len())
+			for j := range parts {
+				parts[j] = fmt.Sprint(val.Index(j).Interface())
+			}
+			sm.SetSetting(key, strings.Join(parts, ","))
+		case reflect.Map:
+			if val.Len() == 0 {
+				sm.SetSetting(key, "")
+				break
+			}
+			data, err := json.Marshal(val.Interface())
+			if err != nil {
+				data = []byte("{}")
+			}
+			sm.SetSetting(key, string(data))
 		default:
 			sm.SetSetting(key, fmt.Sprintf("%v", val.Interface()))
 		}
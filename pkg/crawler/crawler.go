@@ -1,7 +1,11 @@
 package crawler
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"sync"
 
 	"os"
@@ -11,10 +15,10 @@ import (
 	"github.com/djskncxm/NewDuckSpider/internal/setting"
 	"github.com/djskncxm/NewDuckSpider/pkg/item"
 	"github.com/djskncxm/NewDuckSpider/pkg/logger"
+	"github.com/djskncxm/NewDuckSpider/pkg/media"
 	"github.com/djskncxm/NewDuckSpider/pkg/middleware"
 	"github.com/djskncxm/NewDuckSpider/pkg/spider"
 	"github.com/emirpasic/gods/sets/treeset"
-	"gopkg.in/yaml.v3"
 )
 
 type CrawlerManager struct {
@@ -25,77 +29,146 @@ type CrawlerManager struct {
 
 // Crawler 单个爬虫执行器
 type Crawler struct {
-	spider spider.Spider            // 爬虫逻辑
-	engine *core.Engine             // 执行引擎
-	config *setting.SettingsManager // 配置引用
+	spider  spider.SpiderIns         // 爬虫逻辑
+	engine  *core.Engine             // 执行引擎
+	config  *setting.SettingsManager // 配置（共享配置叠加爬虫的 CustomSettings，已冻结）
+	closers []io.Closer              // 爬虫结束后需要关闭的资源（死信文件、媒体索引等）
 }
 
-// NewCrawlerManager 创建爬虫管理器（入口点）
-func NewCrawlerManager() (*CrawlerManager, error) {
+// NewCrawlerManager 创建爬虫管理器（入口点）。configPath 指定配置文件，
+// 不传时依次使用环境变量 DUCKSPIDER_CONFIG，或从当前目录向上查找
+// config/config.yaml（也支持 .yml、.toml、.json），找不到配置文件时使用内置默认值。
+// 不读取命令行参数，需要 --config 与 -s KEY=VALUE 时使用 NewCrawlerManagerFromArgs
+func NewCrawlerManager(configPath ...string) (*CrawlerManager, error) {
+	path := ""
+	if len(configPath) > 0 {
+		path = configPath[0]
+	}
 	// 统一加载配置（仅此一次）
-	config, err := loadConfig("config/config.yaml")
+	config, err := loadConfig(path)
 	if err != nil {
 		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
+	if err := checkConfig(config.Validate()); err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	return newCrawlerManager(config), nil
+}
+
+// NewCrawlerManagerFromArgs 与 NewCrawlerManager 相同，但额外从 args（通常为 os.Args[1:]）中读取
+// "--config PATH" 指定的配置文件和 "-s KEY=VALUE" 形式的配置（优先级最高），返回其余参数。
+// 宿主程序自己的 -s 参数含义不同时，不要使用此函数
+func NewCrawlerManagerFromArgs(args []string) (*CrawlerManager, []string, error) {
+	path, err := setting.ConfigFlag(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	rest, err := config.LoadArgs(args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := checkConfig(config.Validate()); err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+	return newCrawlerManager(config), rest, nil
+}
 
+func newCrawlerManager(config *setting.SettingsManager) *CrawlerManager {
 	return &CrawlerManager{
 		config:   config,
 		crawlers: make(map[string]*Crawler),
 		nameSet:  treeset.NewWithStringComparator(),
-	}, nil
+	}
 }
 
-// loadConfig 集中加载配置（私有方法）
-// loadConfig 加载配置，返回错误而不是panic
+// loadConfig 加载配置，返回错误而不是panic。
+// 按优先级叠加：内置默认值 < 项目配置文件 < 环境变量（DUCK_SECTION__KEY）
 func loadConfig(configPath string) (*setting.SettingsManager, error) {
-	data, err := os.ReadFile(configPath)
+	path, err := setting.FindConfig(configPath)
 	if err != nil {
 		return nil, err
 	}
 
-	var cfg setting.Setting
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, err
-	}
-
 	sm := setting.NewSettingsManager()
-	sm.LoadFromSetting(cfg)
+	sm.LoadDefaults()
+	if path != "" {
+		if err := sm.LoadFile(path, setting.PriorityProject); err != nil {
+			return nil, err
+		}
+	}
+	sm.LoadEnv(setting.EnvPrefix, os.Environ())
 	return sm, nil
 }
 
-// RegisterSpider 注册爬虫
-func (cm *CrawlerManager) RegisterSpider(sp spider.Spider) {
+// checkConfig 报告配置校验发现的问题：未知的键只打印警告，无法解析的值返回错误
+func checkConfig(problems []*setting.SettingError) error {
+	var errs []error
+	for _, p := range problems {
+		if errors.Is(p, setting.ErrUnknownKey) {
+			fmt.Printf("[警告] %v\n", p)
+			continue
+		}
+		errs = append(errs, p)
+	}
+	return errors.Join(errs...)
+}
+
+// RegisterSpider 注册爬虫，sp 可以是任何实现了 SpiderIns 的类型（如 spider.Spider、*spider.CrawlSpider）。
+// 以指针注册时，爬虫可以通过 Opener、Closer、LoggerSetter 接口参与生命周期。
+// 每个爬虫持有共享配置的冻结副本，实现了 CustomSettings 的爬虫在副本上叠加自己的配置，
+// 其优先级高于项目配置、低于环境变量和命令行。CustomSettings 中的值无法按类型解析时返回错误，爬虫不会被注册
+func (cm *CrawlerManager) RegisterSpider(sp spider.SpiderIns) error {
 	name := sp.Name()
 
 	// 检查是否已存在
 	if cm.nameSet.Contains(name) {
 		fmt.Printf("[警告] 爬虫 %s 已存在，跳过注册\n", name)
-		return
+		return nil
 	}
 
-	// 创建爬虫实例（传入统一配置）
+	// 创建爬虫实例（使用统一配置的冻结副本）
+	config := cm.config.Clone()
+	if cs, ok := sp.(spider.CustomSettings); ok {
+		custom := cs.CustomSettings()
+		var problems []*setting.SettingError
+		for k, v := range custom {
+			if err := setting.Check(k, v); err != nil {
+				problems = append(problems, &setting.SettingError{Key: k, Value: v, Source: setting.PriorityName(setting.PrioritySpider), Err: err})
+			}
+		}
+		sort.Slice(problems, func(i, j int) bool { return problems[i].Key < problems[j].Key })
+		if err := checkConfig(problems); err != nil {
+			return fmt.Errorf("爬虫 %s 的配置无效: %w", name, err)
+		}
+		config.SetMany(custom, setting.PrioritySpider)
+	}
+	config.Freeze()
 	crawler := &Crawler{
 		spider: sp,
-		config: cm.config,
+		config: config,
 	}
 
 	// 注册
 	cm.nameSet.Add(name)
 	cm.crawlers[name] = crawler
 
-	loglevel, ok := cm.config.GetString("Spider.LOGLEVEL")
+	loglevel, ok := config.GetString("Spider.LOGLEVEL")
 	if !ok {
 		loglevel = "debug"
 	}
-	LogFormat, ok := cm.config.GetString("Log.LogFormat")
+	LogFormat, ok := config.GetString("Log.LogFormat")
 	if !ok {
 		LogFormat = "text"
 	}
-	EnableConsole := cm.config.GetBool("Log.EnableConsole", true)
-	ConsoleColor := cm.config.GetBool("Log.ConsoleColor", true)
-	EnableFile := cm.config.GetBool("Log.EnableFile", true)
+	EnableConsole := config.GetBool("Log.EnableConsole", true)
+	ConsoleColor := config.GetBool("Log.ConsoleColor", true)
+	EnableFile := config.GetBool("Log.EnableFile", true)
 
-	config := logger.LogConfig{
+	logConfig := logger.LogConfig{
 		AppName:       sp.Name(),
 		LogLevel:      loglevel,
 		LogFormat:     LogFormat,
@@ -103,28 +176,96 @@ func (cm *CrawlerManager) RegisterSpider(sp spider.Spider) {
 		ConsoleColor:  ConsoleColor,
 		EnableFile:    EnableFile,
 		EnableStats:   true,
-		MaxSize:       100, // MB
-		MaxBackups:    10,
-		MaxAge:        30, // days
-		Compress:      true,
+		MaxSize:       config.GetInt("Log.MaxSize", 100), // MB
+		MaxBackups:    config.GetInt("Log.MaxBackups", 10),
+		MaxAge:        config.GetInt("Log.MaxAge", 30), // days
+		Compress:      config.GetBool("Log.Compress", true),
 	}
 
-	MaxSize := cm.config.GetInt("Pipeline.MaxSize", 1000)
-	AutoFlushSize := cm.config.GetInt("Pipeline.AutoFlushSize", 100)
+	MaxSize := config.GetInt("Pipeline.MaxSize", 1000)
+	AutoFlushSize := config.GetInt("Pipeline.AutoFlushSize", 100)
 	PipelineConfig := item.PipelineConfig{
 		MaxSize:       MaxSize,
-		MaxWaitTime:   5 * time.Second,
+		MaxWaitTime:   config.GetDuration("Pipeline.MaxWaitTime", 5*time.Second),
 		AutoFlushSize: AutoFlushSize,
 	}
-	engine := core.InitEngine(sp, cm.config, config, PipelineConfig)
+	engine := core.InitEngine(sp, config, logConfig, PipelineConfig)
 	cm.crawlers[name].engine = &engine
+
+	// 处理失败的项目默认写入 deadletter/<爬虫名>.jsonl，文件在首次写入时创建；配置为空串时不写入
+	if path, ok := config.GetString("Pipeline.DeadLetter"); ok && path != "" {
+		sink, err := item.NewJSONLDeadLetterSink(strings.ReplaceAll(path, "{spider}", name))
+		if err != nil {
+			engine.Logger.Errorf("创建死信存储失败: %v", err)
+		} else {
+			engine.ItemPipeline.SetDeadLetterSink(sink)
+			crawler.closers = append(crawler.closers, sink)
+		}
+	}
+	return nil
 }
 
-func (cm *CrawlerManager) SetPipelineCallback(spider spider.Spider, callback *item.PipelineCallback) {
+func (cm *CrawlerManager) SetPipelineCallback(spider spider.SpiderIns, callback *item.PipelineCallback) {
 	cm.crawlers[spider.Name()].engine.ItemPipeline.SetCallbacks(*callback)
 }
 
-func (cm *CrawlerManager) AddMiddleware(spider spider.Spider, Middleware interface{}, config ...middleware.MiddlewareConfig) {
+// EnableItemDedupe 为指定爬虫的项目管道启用去重
+func (cm *CrawlerManager) EnableItemDedupe(spider spider.SpiderIns, config item.DedupeConfig) error {
+	return cm.crawlers[spider.Name()].engine.ItemPipeline.EnableDedupe(config)
+}
+
+// AddItemSchema 为指定爬虫的项目管道注册声明式字段校验
+func (cm *CrawlerManager) AddItemSchema(spider spider.SpiderIns, schema *item.Schema) error {
+	return cm.crawlers[spider.Name()].engine.ItemPipeline.AddSchema(schema)
+}
+
+// AddMediaPipeline 为指定爬虫添加文件下载处理器，下载经过该爬虫的下载器和中间件。
+// 处理器按注册顺序执行，应在保存项目的处理器之前调用
+func (cm *CrawlerManager) AddMediaPipeline(spider spider.SpiderIns, config media.Config) error {
+	c := cm.crawlers[spider.Name()]
+	store, err := media.NewFSStore(config.StoreDir)
+	if err != nil {
+		return err
+	}
+	files, err := media.NewFilesPipeline(c.engine, store, config, c.engine.Logger)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	c.engine.ItemPipeline.AddNamedProcessor("media.FilesPipeline", files.Process)
+	c.closers = append(c.closers, store)
+	return nil
+}
+
+// AddImagesPipeline 为指定爬虫添加图片下载处理器，在文件下载之外过滤小图、转换格式并生成缩略图
+func (cm *CrawlerManager) AddImagesPipeline(spider spider.SpiderIns, config media.ImagesConfig) error {
+	c := cm.crawlers[spider.Name()]
+	store, err := media.NewFSStore(config.StoreDir)
+	if err != nil {
+		return err
+	}
+	images, err := media.NewImagesPipeline(c.engine, store, config, c.engine.Logger)
+	if err != nil {
+		store.Close()
+		return err
+	}
+	c.engine.ItemPipeline.AddNamedProcessor("media.ImagesPipeline", images.Process)
+	c.closers = append(c.closers, store)
+	return nil
+}
+
+// SetDeadLetterSink 为指定爬虫设置死信存储
+func (cm *CrawlerManager) SetDeadLetterSink(spider spider.SpiderIns, sink item.DeadLetterSink) {
+	cm.crawlers[spider.Name()].engine.ItemPipeline.SetDeadLetterSink(sink)
+}
+
+// ReplayDeadLetters 将死信文件中的项目重新送入指定爬虫的处理器链，
+// 应在修复处理器后、注册完处理器之后调用
+func (cm *CrawlerManager) ReplayDeadLetters(spider spider.SpiderIns, path string) (succeeded int, failed int, err error) {
+	return cm.crawlers[spider.Name()].engine.ItemPipeline.ReplayDeadLetters(path)
+}
+
+func (cm *CrawlerManager) AddMiddleware(spider spider.SpiderIns, Middleware interface{}, config ...middleware.MiddlewareConfig) {
 	cm.crawlers[spider.Name()].engine.MiddlewareManager.Register(Middleware, config...)
 }
 
@@ -154,13 +295,37 @@ func (cm *CrawlerManager) StartAll() {
 					c.engine.Logger.PrintStats()
 				}
 			}()
-			c.engine.StartSpider()
+			if err := c.engine.StartSpider(); err != nil {
+				c.engine.Logger.Error(err)
+			}
+			// StartSpider 返回时项目管道已处理完全部项目，此时才关闭死信文件、媒体索引等资源
+			for _, closer := range c.closers {
+				closer.Close()
+			}
 			c.engine.Logger.PrintStats()
 		}(crawler, name)
 	}
 	wg.Wait()
 }
 
+// Settings 返回指定爬虫生效的配置（冻结副本），爬虫未注册时返回 nil
+func (cm *CrawlerManager) Settings(spider spider.SpiderIns) *setting.SettingsManager {
+	c := cm.crawlers[spider.Name()]
+	if c == nil {
+		return nil
+	}
+	return c.config
+}
+
+// DumpSettings 输出指定爬虫生效的配置及每个值的来源
+func (cm *CrawlerManager) DumpSettings(spider spider.SpiderIns, w io.Writer) error {
+	config := cm.Settings(spider)
+	if config == nil {
+		return fmt.Errorf("爬虫 %s 未注册", spider.Name())
+	}
+	return config.Dump(w)
+}
+
 // GetConfig 获取配置管理器（如果需要外部访问配置）
 func (cm *CrawlerManager) GetConfig() *setting.SettingsManager {
 	return cm.config
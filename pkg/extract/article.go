@@ -0,0 +1,767 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article 从文章页提取的正文与元数据
+type Article struct {
+	Title     string
+	Byline    string    // 作者
+	Published time.Time // 发布时间，未找到时为零值
+	Excerpt   string    // 摘要，取自 description，没有时为正文第一段
+	SiteName  string
+	Lang      string
+	Image     string     // 题图的绝对地址
+	HTML      string     // 清理后的正文 HTML，链接与图片为绝对地址
+	Text      string     // 正文纯文本，段落之间以空行分隔
+	Content   *html.Node // 正文根节点（<div>），是原文档的副本，修改不会影响响应
+}
+
+// ArticleConfig 正文提取配置
+type ArticleConfig struct {
+	MinParagraphLength int // 参与打分的段落最少字符数，默认 25
+	CharThreshold      int // 正文少于该字符数时放宽清理规则重试，默认 500
+}
+
+// 正文打分使用的类名 / id 规则
+var (
+	unlikelyRe = regexp.MustCompile(`(?i)-ad-|ai2html|banner|breadcrumb|combx|comment|community|cookie|cover-wrap|disqus|extra|footer|gdpr|header|legends|menu|newsletter|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|ad-break|agegate|pagination|pager|popup`)
+	maybeRe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveRe = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeRe = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|contact|foot|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|^ads?$|^ads?[-_ ]|[-_ ]ads?$`)
+	bylineRe   = regexp.MustCompile(`(?i)byline|author|dateline|writtenby|p-author`)
+	sentenceRe = regexp.MustCompile(`\.( |$)|[。！？]`)
+	titleSepRe = regexp.MustCompile(`\s+[|\-_–—·»:]\s+|\s*[｜|_]\s*`)
+)
+
+// articleRemoveTags 始终删除的元素
+var articleRemoveTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "link": true, "meta": true,
+	"iframe": true, "object": true, "embed": true, "svg": true, "canvas": true,
+	"form": true, "button": true, "input": true, "select": true, "textarea": true,
+	"nav": true, "aside": true, "footer": true, "dialog": true,
+}
+
+// articleRemoveRoles 始终删除的 ARIA 角色
+var articleRemoveRoles = map[string]bool{
+	"menu": true, "menubar": true, "complementary": true, "navigation": true,
+	"alert": true, "alertdialog": true, "dialog": true, "banner": true, "contentinfo": true,
+}
+
+// paragraphChildTags 包含这些元素的 <div> 不作为段落打分
+var paragraphChildTags = map[string]bool{
+	"a": true, "blockquote": true, "dl": true, "div": true, "img": true, "ol": true,
+	"p": true, "pre": true, "table": true, "ul": true, "section": true, "article": true,
+}
+
+// articleKeepAttrs 正文中保留的属性
+var articleKeepAttrs = map[string]bool{
+	"href": true, "src": true, "srcset": true, "alt": true, "title": true,
+	"colspan": true, "rowspan": true, "datetime": true, "lang": true,
+}
+
+// articleBlockTags 生成纯文本时按段落分隔的元素
+var articleBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "tr": true, "figure": true, "figcaption": true, "hr": true, "header": true, "main": true,
+}
+
+// ExtractArticle 不依赖站点选择器提取文章正文：按段落给候选容器打分，选出得分最高的容器及其相关的兄弟节点，
+// 删除导航、广告、评论等模板内容后返回清理过的 HTML 与纯文本，并从 meta、JSON-LD 和页面中提取标题、作者与发布时间。
+// 响应的文档树不会被修改
+func ExtractArticle(resp *httpc.Response, config ...ArticleConfig) (*Article, error) {
+	var cfg ArticleConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MinParagraphLength <= 0 {
+		cfg.MinParagraphLength = 25
+	}
+	if cfg.CharThreshold <= 0 {
+		cfg.CharThreshold = 500
+	}
+
+	root := resp.Selector().Node()
+	if root == nil {
+		if err := resp.Error(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("article: 只支持 HTML 文档")
+	}
+	base, err := resp.BaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Article{}
+	a.readMetadata(resp, root, base)
+
+	// 依次放宽规则：先删除疑似模板的元素并做条件清理，正文过短时逐步关闭
+	attempts := []articleParser{
+		{cfg: cfg, title: a.Title, stripUnlikely: true, cleanConditionally: true},
+		{cfg: cfg, title: a.Title, cleanConditionally: true},
+		{cfg: cfg, title: a.Title},
+	}
+	// 都达不到阈值时使用规则最严格的结果，避免放宽后混入模板内容
+	var best *html.Node
+	var bestByline string
+	for i := range attempts {
+		p := &attempts[i]
+		content := p.grab(cloneNode(root))
+		if content == nil {
+			continue
+		}
+		enough := utf8.RuneCountInString(innerText(content)) >= cfg.CharThreshold
+		if best == nil || enough {
+			best, bestByline = content, p.byline
+		}
+		if enough {
+			break
+		}
+	}
+	if best == nil {
+		return nil, errors.New("article: 未找到正文")
+	}
+
+	finishContent(best, base)
+	var buf bytes.Buffer
+	for c := best.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&buf, c); err != nil {
+			return nil, err
+		}
+	}
+	a.Content = best
+	a.HTML = buf.String()
+	a.Text = blockText(best)
+	if a.Byline == "" {
+		a.Byline = bestByline
+	}
+	if a.Excerpt == "" {
+		a.Excerpt, _, _ = strings.Cut(a.Text, "\n\n")
+	}
+	return a, nil
+}
+
+// articleParser 单次正文提取
+type articleParser struct {
+	cfg                ArticleConfig
+	title              string
+	stripUnlikely      bool
+	cleanConditionally bool
+	byline             string // 从页面中删除的作者行
+	scores             map[*html.Node]float64
+}
+
+// grab 在文档副本上提取正文，返回包含正文的 <div>
+func (p *articleParser) grab(doc *html.Node) *html.Node {
+	body := findElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+	p.prepare(body)
+
+	// 给段落的祖先节点打分
+	p.scores = make(map[*html.Node]float64)
+	var candidates []*html.Node
+	for _, n := range elements(body) {
+		if !isParagraph(n) {
+			continue
+		}
+		text := innerText(n)
+		length := utf8.RuneCountInString(text)
+		if length < p.cfg.MinParagraphLength {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + min(float64(length)/100, 3)
+		level := 0
+		for anc := n.Parent; anc != nil && anc.Type == html.ElementNode && level < 3; anc = anc.Parent {
+			if _, ok := p.scores[anc]; !ok {
+				p.scores[anc] = initialScore(anc)
+				candidates = append(candidates, anc)
+			}
+			divider := 1.0
+			if level == 1 {
+				divider = 2
+			} else if level > 1 {
+				divider = float64(level * 3)
+			}
+			p.scores[anc] += score / divider
+			level++
+		}
+	}
+
+	// 得分按链接密度折算，取最高者
+	var top *html.Node
+	topScore := 0.0
+	for _, c := range candidates {
+		p.scores[c] *= 1 - linkDensity(c)
+		if top == nil || p.scores[c] > topScore {
+			top, topScore = c, p.scores[c]
+		}
+	}
+
+	content := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	if top == nil || top == body {
+		// 没有明显的正文容器时使用整个 <body>
+		for c := body.FirstChild; c != nil; {
+			next := c.NextSibling
+			body.RemoveChild(c)
+			content.AppendChild(c)
+			c = next
+		}
+	} else {
+		p.gatherSiblings(top, topScore, content)
+	}
+
+	p.clean(content)
+	if strings.TrimSpace(innerText(content)) == "" {
+		return nil
+	}
+	return content
+}
+
+// prepare 删除注释、隐藏元素与模板元素，并记录作者行
+func (p *articleParser) prepare(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.CommentNode:
+			n.RemoveChild(c)
+		case html.ElementNode:
+			if p.shouldRemove(c) {
+				n.RemoveChild(c)
+			} else {
+				p.prepare(c)
+			}
+		}
+		c = next
+	}
+}
+
+// shouldRemove 判断元素是否为正文之外的内容
+func (p *articleParser) shouldRemove(n *html.Node) bool {
+	if articleRemoveTags[n.Data] || isHidden(n) {
+		return true
+	}
+	if role, _ := attr(n, "role"); articleRemoveRoles[strings.ToLower(role)] {
+		return true
+	}
+
+	match := classAndID(n)
+	if p.byline == "" && isByline(n, match) {
+		if text := innerText(n); text != "" && utf8.RuneCountInString(text) < 100 {
+			p.byline = text
+			return true
+		}
+	}
+	if !p.stripUnlikely || match == "" {
+		return false
+	}
+	switch n.Data {
+	case "body", "a", "article", "main", "table", "tbody", "tr", "td", "th", "code", "pre":
+		return false
+	}
+	return unlikelyRe.MatchString(match) && !maybeRe.MatchString(match) && !hasAncestor(n, "table", "code")
+}
+
+// gatherSiblings 将最佳候选及与其相关的兄弟节点移入 content
+func (p *articleParser) gatherSiblings(top *html.Node, topScore float64, content *html.Node) {
+	parent := top.Parent
+	threshold := max(10, topScore*0.2)
+	topClass, _ := attr(top, "class")
+
+	var keep []*html.Node
+	for s := parent.FirstChild; s != nil; s = s.NextSibling {
+		if s.Type != html.ElementNode {
+			continue
+		}
+		if s == top {
+			keep = append(keep, s)
+			continue
+		}
+		bonus := 0.0
+		if class, _ := attr(s, "class"); class != "" && class == topClass {
+			bonus = topScore * 0.2
+		}
+		if score, ok := p.scores[s]; ok && score+bonus >= threshold {
+			keep = append(keep, s)
+			continue
+		}
+		if s.Data == "p" {
+			text := innerText(s)
+			length := utf8.RuneCountInString(text)
+			density := linkDensity(s)
+			if (length > 80 && density < 0.25) || (length > 0 && length <= 80 && density == 0 && sentenceRe.MatchString(text)) {
+				keep = append(keep, s)
+			}
+		}
+	}
+	for _, s := range keep {
+		parent.RemoveChild(s)
+		content.AppendChild(s)
+	}
+}
+
+// clean 清理正文中的重复标题、低质量区块与空段落
+func (p *articleParser) clean(content *html.Node) {
+	nodes := elements(content)
+	// 从内到外处理，子节点先于父节点
+	for i := len(nodes) - 1; i >= 1; i-- {
+		n := nodes[i]
+		if n.Parent == nil {
+			continue
+		}
+		remove := false
+		switch n.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			text := innerText(n)
+			remove = classWeight(n) < 0 || text == "" || (p.title != "" && text == p.title)
+		case "p":
+			remove = innerText(n) == "" && len(findElements(n, "img", "picture", "video", "audio")) == 0
+		case "div", "section", "table", "ul", "ol":
+			remove = p.cleanConditionally && p.isFishy(n)
+		}
+		if remove {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// isFishy 判断区块是否为广告、推荐列表等模板内容
+func (p *articleParser) isFishy(n *html.Node) bool {
+	if n.Data == "table" && (findElement(n, "th") != nil || findElement(n, "caption") != nil) {
+		return false // 数据表格
+	}
+	weight := classWeight(n)
+	if float64(weight)+p.scores[n] < 0 {
+		return true
+	}
+
+	text := innerText(n)
+	if strings.Count(text, ",")+strings.Count(text, "，") >= 10 {
+		return false
+	}
+	isList := n.Data == "ul" || n.Data == "ol"
+	inFigure := hasAncestor(n, "figure")
+	paras := len(findElements(n, "p"))
+	imgs := len(findElements(n, "img"))
+	lis := len(findElements(n, "li")) - 100
+	inputs := len(findElements(n, "input"))
+	length := utf8.RuneCountInString(text)
+	density := linkDensity(n)
+
+	switch {
+	case imgs > 1 && float64(paras)/float64(imgs) < 0.5 && !inFigure:
+		return true
+	case !isList && lis > paras:
+		return true
+	case inputs > paras/3:
+		return true
+	case !isList && length < p.cfg.MinParagraphLength && (imgs == 0 || imgs > 2) && !inFigure:
+		return true
+	case !isList && weight < 25 && density > 0.2:
+		return true
+	case weight >= 25 && density > 0.5:
+		return true
+	}
+	return false
+}
+
+// readMetadata 从 meta、JSON-LD 与页面元素中读取元数据
+func (a *Article) readMetadata(resp *httpc.Response, root *html.Node, base *url.URL) {
+	meta := make(map[string]string)
+	for _, n := range findElements(root, "meta") {
+		content, ok := attr(n, "content")
+		content = strings.TrimSpace(content)
+		if !ok || content == "" {
+			continue
+		}
+		for _, key := range []string{"property", "name", "itemprop"} {
+			if v, _ := attr(n, key); v != "" {
+				if k := strings.ToLower(strings.TrimSpace(v)); meta[k] == "" {
+					meta[k] = content
+				}
+			}
+		}
+	}
+
+	// JSON-LD 中的文章对象
+	var ld gjson.Result
+	for _, r := range resp.JSONLD() {
+		for _, obj := range append([]gjson.Result{r}, r.Get("@graph").Array()...) {
+			if t := obj.Get("@type").String(); strings.Contains(t, "Article") || strings.Contains(t, "Posting") {
+				ld = obj
+				break
+			}
+		}
+		if ld.Exists() {
+			break
+		}
+	}
+
+	if doc := findElement(root, "html"); doc != nil {
+		a.Lang, _ = attr(doc, "lang")
+	}
+	a.Title = firstNonEmpty(meta["og:title"], meta["twitter:title"], ld.Get("headline").String(), documentTitle(root))
+	a.SiteName = firstNonEmpty(meta["og:site_name"], meta["application-name"], ld.Get("publisher.name").String())
+	a.Excerpt = firstNonEmpty(meta["description"], meta["og:description"], meta["twitter:description"], ld.Get("description").String())
+
+	a.Byline = firstNonEmpty(ldAuthor(ld.Get("author")), meta["author"], meta["parsely-author"], meta["dc.creator"], meta["sailthru.author"])
+	if v := meta["article:author"]; a.Byline == "" && v != "" && !strings.HasPrefix(v, "http") {
+		a.Byline = v
+	}
+	if a.Byline == "" {
+		for _, n := range elements(root) {
+			rel, _ := attr(n, "rel")
+			prop, _ := attr(n, "itemprop")
+			if hasToken(rel, "author") || hasToken(prop, "author") {
+				if text := innerText(n); text != "" && utf8.RuneCountInString(text) < 100 {
+					a.Byline = text
+					break
+				}
+			}
+		}
+	}
+
+	date := firstNonEmpty(meta["article:published_time"], ld.Get("datePublished").String(), meta["datepublished"],
+		meta["pubdate"], meta["publishdate"], meta["publish-date"], meta["parsely-pub-date"], meta["sailthru.date"],
+		meta["dc.date.issued"], meta["dc.date"], meta["date"], meta["og:updated_time"])
+	if date == "" {
+		for _, n := range findElements(root, "time") {
+			if v, _ := attr(n, "datetime"); v != "" {
+				date = v
+				break
+			}
+		}
+	}
+	a.Published = parseArticleDate(date)
+
+	if img := firstNonEmpty(meta["og:image"], meta["twitter:image"], meta["twitter:image:src"]); img != "" {
+		a.Image = resolveRef(base, img)
+	}
+}
+
+// documentTitle 返回 <title>，去掉 " - 站点名" 之类的后缀；<h1> 与标题一致时使用 <h1>
+func documentTitle(root *html.Node) string {
+	var title string
+	if n := findElement(root, "title"); n != nil {
+		title = innerText(n)
+	}
+	if h1s := findElements(root, "h1"); len(h1s) == 1 {
+		if h1 := innerText(h1s[0]); h1 != "" && (title == "" || strings.Contains(title, h1)) {
+			return h1
+		}
+	}
+	if loc := titleSepRe.FindAllStringIndex(title, -1); len(loc) > 0 {
+		last := loc[len(loc)-1]
+		head, tail := title[:last[0]], title[last[1]:]
+		if utf8.RuneCountInString(head) >= utf8.RuneCountInString(tail) {
+			return strings.TrimSpace(head)
+		}
+	}
+	return title
+}
+
+// ldAuthor 读取 JSON-LD 的 author，可能是字符串、对象或数组
+func ldAuthor(v gjson.Result) string {
+	if v.IsArray() {
+		var names []string
+		for _, a := range v.Array() {
+			if name := ldAuthor(a); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	if v.IsObject() {
+		return strings.TrimSpace(v.Get("name").String())
+	}
+	return strings.TrimSpace(v.String())
+}
+
+// articleDateLayouts 订阅源格式之外，文章页常见的日期格式
+var articleDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006年1月2日 15:04:05",
+	"2006年1月2日 15:04",
+	"2006年1月2日",
+	"January 2, 2006",
+	"Jan 2, 2006",
+}
+
+// parseArticleDate 解析发布时间，失败时返回零值
+func parseArticleDate(s string) time.Time {
+	if t := parseFeedDate(s); !t.IsZero() {
+		return t
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range articleDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// finishContent 删除多余属性，将链接与图片地址解析为绝对地址（含懒加载图片）
+func finishContent(content *html.Node, base *url.URL) {
+	for _, n := range elements(content) {
+		if n.Data == "img" {
+			src, _ := attr(n, "src")
+			if src == "" || strings.HasPrefix(src, "data:") {
+				for _, key := range []string{"data-src", "data-original", "data-lazy-src"} {
+					if v, _ := attr(n, key); v != "" {
+						setAttr(n, "src", v)
+						break
+					}
+				}
+			}
+		}
+		kept := n.Attr[:0]
+		for _, a := range n.Attr {
+			if !articleKeepAttrs[a.Key] {
+				continue
+			}
+			if a.Key == "href" || a.Key == "src" {
+				a.Val = resolveRef(base, a.Val)
+			}
+			kept = append(kept, a)
+		}
+		n.Attr = kept
+	}
+}
+
+// blockText 生成纯文本，块级元素之间以空行分隔，<br> 换行
+func blockText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(cur *html.Node) {
+		switch cur.Type {
+		case html.TextNode:
+			// 保留首尾空白的位置，换行只由 <br> 与块级元素产生
+			s := cur.Data
+			if s != "" && isSpace(rune(s[0])) {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strings.Join(strings.FieldsFunc(s, isSpace), " "))
+			if s != "" && isSpace(rune(s[len(s)-1])) {
+				b.WriteByte(' ')
+			}
+			return
+		case html.ElementNode:
+			if cur.Data == "br" {
+				b.WriteByte('\n')
+				return
+			}
+			if articleBlockTags[cur.Data] {
+				b.WriteString("\n\n")
+				defer b.WriteString("\n\n")
+			}
+		}
+		for c := cur.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	var paras []string
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		} else if len(lines) > 0 {
+			paras = append(paras, strings.Join(lines, "\n"))
+			lines = nil
+		}
+	}
+	if len(lines) > 0 {
+		paras = append(paras, strings.Join(lines, "\n"))
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// isSpace 判断 HTML 空白字符（不含换行以外的 Unicode 空白）
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// isParagraph 判断元素是否作为段落打分：p、pre、td、h2-h6，以及不含块级子元素的 <div>
+func isParagraph(n *html.Node) bool {
+	switch n.Data {
+	case "p", "pre", "td", "h2", "h3", "h4", "h5", "h6":
+		return true
+	case "div", "section":
+		for _, d := range elements(n)[1:] {
+			if paragraphChildTags[d.Data] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// initialScore 候选容器按标签与类名的初始得分
+func initialScore(n *html.Node) float64 {
+	score := float64(classWeight(n))
+	switch n.Data {
+	case "div", "article", "main":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+// classWeight 按类名与 id 判断正文倾向，正面 +25，负面 -25
+func classWeight(n *html.Node) int {
+	weight := 0
+	for _, key := range []string{"class", "id"} {
+		v, _ := attr(n, key)
+		if v == "" {
+			continue
+		}
+		if negativeRe.MatchString(v) {
+			weight -= 25
+		}
+		if positiveRe.MatchString(v) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity 链接文本占全部文本的比例
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(innerText(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	for _, a := range findElements(n, "a") {
+		links += utf8.RuneCountInString(innerText(a))
+	}
+	return min(float64(links)/float64(total), 1)
+}
+
+// isHidden 判断元素是否被隐藏
+func isHidden(n *html.Node) bool {
+	if _, ok := attr(n, "hidden"); ok {
+		return true
+	}
+	if v, _ := attr(n, "aria-hidden"); v == "true" && n.Data != "img" {
+		return true
+	}
+	style, _ := attr(n, "style")
+	style = strings.ReplaceAll(strings.ToLower(style), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// isByline 判断元素是否为作者行
+func isByline(n *html.Node, match string) bool {
+	rel, _ := attr(n, "rel")
+	prop, _ := attr(n, "itemprop")
+	return hasToken(rel, "author") || strings.Contains(prop, "author") || (match != "" && bylineRe.MatchString(match))
+}
+
+// classAndID 返回 class 与 id 拼接后的字符串
+func classAndID(n *html.Node) string {
+	class, _ := attr(n, "class")
+	id, _ := attr(n, "id")
+	return strings.TrimSpace(class + " " + id)
+}
+
+// hasAncestor 判断元素是否位于指定标签内
+func hasAncestor(n *html.Node, tags ...string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		for _, t := range tags {
+			if p.Type == html.ElementNode && p.Data == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findElements 返回 n 的后代中指定标签的元素（不含 n 自身）
+func findElements(n *html.Node, tags ...string) []*html.Node {
+	var res []*html.Node
+	for _, e := range elements(n) {
+		if e == n {
+			continue
+		}
+		for _, t := range tags {
+			if e.Data == t {
+				res = append(res, e)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// findElement 返回 n 的后代中第一个指定标签的元素
+func findElement(n *html.Node, tag string) *html.Node {
+	if res := findElements(n, tag); len(res) > 0 {
+		return res[0]
+	}
+	return nil
+}
+
+// setAttr 设置属性，不存在时追加
+func setAttr(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// resolveRef 将地址解析为绝对地址，失败时原样返回
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	u, err := url.Parse(ref)
+	if err != nil || ref == "" {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+// cloneNode 深拷贝节点
+func cloneNode(n *html.Node) *html.Node {
+	c := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		c.AppendChild(cloneNode(ch))
+	}
+	return c
+}
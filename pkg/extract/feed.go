@@ -0,0 +1,320 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+)
+
+// 订阅源格式
+const (
+	FeedRSS  = "rss"  // RSS 0.9x / 2.0
+	FeedRDF  = "rdf"  // RSS 1.0
+	FeedAtom = "atom" // Atom 1.0
+)
+
+// Enclosure 条目附件（播客音频、图片等）
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// FeedEntry 统一格式的订阅条目
+type FeedEntry struct {
+	ID         string
+	Title      string
+	Link       string // 绝对地址
+	Summary    string
+	Content    string // 全文 HTML（RSS 的 content:encoded、Atom 的 content）
+	Author     string
+	Published  time.Time // 无法解析时为零值
+	Updated    time.Time
+	Categories []string
+	Enclosures []Enclosure
+}
+
+// Feed 统一格式的订阅源
+type Feed struct {
+	Type        string // FeedRSS、FeedRDF 或 FeedAtom
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Entries     []FeedEntry
+}
+
+// ParseFeed 将 RSS / Atom 响应解析为统一格式，不依赖 Content-Type，
+// 条目中的相对链接按响应地址解析为绝对地址
+func ParseFeed(resp *httpc.Response) (*Feed, error) {
+	root := resp.XMLSelector()
+	if err := resp.ParseXML(); err != nil {
+		return nil, fmt.Errorf("feed: 解析失败: %w", err)
+	}
+
+	doc := firstElement(root.XMLNode())
+	if doc == nil {
+		return nil, errors.New("feed: 文档为空")
+	}
+
+	var feed *Feed
+	switch strings.ToLower(doc.Data) {
+	case "rss":
+		feed = parseRSS(childElem(doc, "channel"), nil)
+		feed.Type = FeedRSS
+	case "rdf":
+		// RSS 1.0 的 item 与 channel 同级
+		feed = parseRSS(childElem(doc, "channel"), doc)
+		feed.Type = FeedRDF
+	case "feed":
+		feed = parseAtom(doc)
+	default:
+		return nil, fmt.Errorf("feed: 未知的根元素 <%s>", doc.Data)
+	}
+
+	feed.Link = joinURL(resp, feed.Link)
+	for i := range feed.Entries {
+		e := &feed.Entries[i]
+		e.Link = joinURL(resp, e.Link)
+		for j := range e.Enclosures {
+			e.Enclosures[j].URL = joinURL(resp, e.Enclosures[j].URL)
+		}
+	}
+	return feed, nil
+}
+
+// parseRSS 解析 RSS channel，items 为 item 元素所在的父节点
+func parseRSS(channel, items *xmlquery.Node) *Feed {
+	feed := &Feed{}
+	if channel != nil {
+		feed.Title = childText(channel, "title")
+		feed.Link = childTextNS(channel, "", "link") // 忽略 <atom:link rel="self">
+		feed.Description = childText(channel, "description")
+		feed.Updated = parseFeedDate(firstNonEmpty(childText(channel, "lastBuildDate"), childText(channel, "pubDate"), childText(channel, "date")))
+		// RSS 2.0 的 item 在 channel 内
+		if childElem(channel, "item") != nil {
+			items = channel
+		}
+	}
+	if items == nil {
+		return feed
+	}
+
+	for _, it := range childElems(items, "item") {
+		entry := FeedEntry{
+			ID:        childText(it, "guid"),
+			Title:     childText(it, "title"),
+			Link:      childTextNS(it, "", "link"),
+			Summary:   childText(it, "description"),
+			Content:   childTextNS(it, "content", "encoded"),
+			Author:    firstNonEmpty(childText(it, "author"), childText(it, "creator")),
+			Published: parseFeedDate(firstNonEmpty(childText(it, "pubDate"), childText(it, "date"))),
+		}
+		if entry.ID == "" {
+			entry.ID = firstNonEmpty(it.SelectAttr("rdf:about"), entry.Link)
+		}
+		entry.Updated = entry.Published
+		for _, c := range childElems(it, "category") {
+			if text := strings.TrimSpace(c.InnerText()); text != "" {
+				entry.Categories = append(entry.Categories, text)
+			}
+		}
+		for _, enc := range childElems(it, "enclosure") {
+			length, _ := strconv.ParseInt(enc.SelectAttr("length"), 10, 64)
+			entry.Enclosures = append(entry.Enclosures, Enclosure{
+				URL:    enc.SelectAttr("url"),
+				Type:   enc.SelectAttr("type"),
+				Length: length,
+			})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// parseAtom 解析 Atom feed
+func parseAtom(doc *xmlquery.Node) *Feed {
+	feed := &Feed{
+		Type:        FeedAtom,
+		Title:       childText(doc, "title"),
+		Link:        atomLink(doc),
+		Description: childText(doc, "subtitle"),
+		Updated:     parseFeedDate(childText(doc, "updated")),
+	}
+	feedAuthor := atomAuthor(doc)
+
+	for _, e := range childElems(doc, "entry") {
+		entry := FeedEntry{
+			ID:        childText(e, "id"),
+			Title:     childText(e, "title"),
+			Link:      atomLink(e),
+			Summary:   atomContent(childElem(e, "summary")),
+			Content:   atomContent(childElem(e, "content")),
+			Author:    firstNonEmpty(atomAuthor(e), feedAuthor),
+			Published: parseFeedDate(firstNonEmpty(childText(e, "published"), childText(e, "issued"))),
+			Updated:   parseFeedDate(firstNonEmpty(childText(e, "updated"), childText(e, "modified"))),
+		}
+		if entry.Published.IsZero() {
+			entry.Published = entry.Updated
+		}
+		for _, c := range childElems(e, "category") {
+			if term := firstNonEmpty(c.SelectAttr("label"), c.SelectAttr("term")); term != "" {
+				entry.Categories = append(entry.Categories, term)
+			}
+		}
+		for _, l := range childElems(e, "link") {
+			if l.SelectAttr("rel") == "enclosure" {
+				length, _ := strconv.ParseInt(l.SelectAttr("length"), 10, 64)
+				entry.Enclosures = append(entry.Enclosures, Enclosure{
+					URL:    l.SelectAttr("href"),
+					Type:   l.SelectAttr("type"),
+					Length: length,
+				})
+			}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+// atomLink 返回 rel="alternate"（或未指定 rel）的链接
+func atomLink(n *xmlquery.Node) string {
+	for _, l := range childElems(n, "link") {
+		if rel := l.SelectAttr("rel"); rel == "" || rel == "alternate" {
+			return l.SelectAttr("href")
+		}
+	}
+	return ""
+}
+
+// atomAuthor 返回 <author><name> 的内容
+func atomAuthor(n *xmlquery.Node) string {
+	if author := childElem(n, "author"); author != nil {
+		return firstNonEmpty(childText(author, "name"), strings.TrimSpace(author.InnerText()))
+	}
+	return ""
+}
+
+// atomContent 返回 Atom 文本构造的内容，type="xhtml" 时返回内部 XHTML
+func atomContent(n *xmlquery.Node) string {
+	if n == nil {
+		return ""
+	}
+	if n.SelectAttr("type") == "xhtml" {
+		if div := childElem(n, "div"); div != nil {
+			return strings.TrimSpace(div.OutputXML(false))
+		}
+		return strings.TrimSpace(n.OutputXML(false))
+	}
+	return strings.TrimSpace(n.InnerText())
+}
+
+// firstElement 返回文档的根元素
+func firstElement(n *xmlquery.Node) *xmlquery.Node {
+	if n == nil {
+		return nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// childElems 返回本地名为 name 的子元素（忽略命名空间前缀，不区分大小写）
+func childElems(n *xmlquery.Node, name string) []*xmlquery.Node {
+	var res []*xmlquery.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode && strings.EqualFold(c.Data, name) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// childElem 返回第一个本地名为 name 的子元素
+func childElem(n *xmlquery.Node, name string) *xmlquery.Node {
+	if n == nil {
+		return nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode && strings.EqualFold(c.Data, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// childText 返回第一个本地名为 name 的子元素的文本
+func childText(n *xmlquery.Node, name string) string {
+	if c := childElem(n, name); c != nil {
+		return strings.TrimSpace(c.InnerText())
+	}
+	return ""
+}
+
+// childTextNS 返回带指定前缀的子元素文本（如 content:encoded），prefix 为空表示无前缀
+func childTextNS(n *xmlquery.Node, prefix, name string) string {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode && c.Prefix == prefix && c.Data == name {
+			return strings.TrimSpace(c.InnerText())
+		}
+	}
+	return ""
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// feedDateLayouts RSS（RFC 822）与 Atom（RFC 3339）常见的日期格式
+var feedDateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedDate 解析订阅源日期，失败时返回零值
+func parseFeedDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// joinURL 将链接解析为绝对地址，失败时原样返回
+func joinURL(resp *httpc.Response, href string) string {
+	if href == "" {
+		return ""
+	}
+	if abs, err := resp.URLJoin(href); err == nil {
+		return abs
+	}
+	return href
+}
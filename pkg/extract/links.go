@@ -0,0 +1,364 @@
+package extract
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+	"golang.org/x/net/html"
+)
+
+// Link 从页面中提取到的链接
+type Link struct {
+	URL      string // 绝对地址（不含片段）
+	Text     string // 链接文本
+	Fragment string // # 之后的片段
+	NoFollow bool   // rel 属性包含 nofollow
+}
+
+// DefaultDenyExtensions 默认忽略的文件扩展名（图片、音视频、压缩包、文档等）
+var DefaultDenyExtensions = []string{
+	// 图片
+	"mng", "pct", "bmp", "gif", "jpg", "jpeg", "png", "pst", "psp", "tif", "tiff", "ai", "drw", "dxf", "eps", "ps", "svg", "cdr", "ico", "webp",
+	// 音频
+	"mp3", "wma", "ogg", "wav", "ra", "aac", "mid", "au", "aiff",
+	// 视频
+	"3gp", "asf", "asx", "avi", "mov", "mp4", "mpg", "qt", "rm", "swf", "wmv", "m4a", "m4v", "flv", "webm",
+	// 压缩包
+	"7z", "7zip", "apk", "bz2", "cab", "deb", "dmg", "gz", "iso", "jar", "lz", "rar", "rpm", "tar", "tgz", "xz", "zip",
+	// 办公文档
+	"xls", "xlsx", "ppt", "pptx", "pps", "doc", "docx", "odt", "ods", "odg", "odp", "pdf",
+	// 其他
+	"css", "exe", "bin", "msi",
+}
+
+// LinkExtractorConfig 链接提取规则
+type LinkExtractorConfig struct {
+	Allow           []string            // URL 需匹配其中任一正则，为空表示全部允许
+	Deny            []string            // URL 匹配其中任一正则即排除，优先于 Allow
+	AllowDomains    []string            // 只保留这些域名（含子域名）
+	DenyDomains     []string            // 排除这些域名（含子域名）
+	RestrictCSS     []string            // 只在这些 CSS 选择器匹配的区域内提取
+	RestrictXPath   []string            // 只在这些 XPath 匹配的区域内提取
+	Tags            []string            // 提取的标签，默认 a、area
+	Attrs           []string            // 提取的属性，默认 href
+	DenyExtensions  []string            // 排除的扩展名（不带点），为 nil 时使用 DefaultDenyExtensions，传空切片表示不排除
+	Canonicalize    bool                // 规范化 URL（小写主机、去默认端口、排序查询参数）
+	AllowDuplicates bool                // 保留重复链接，默认去重
+	ProcessValue    func(string) string // 对属性值的预处理，返回空字符串表示丢弃
+}
+
+// LinkExtractor 按规则从响应中提取链接，创建后可并发使用
+type LinkExtractor struct {
+	config     LinkExtractorConfig
+	allow      []*regexp.Regexp
+	deny       []*regexp.Regexp
+	tags       map[string]struct{}
+	attrs      []string
+	extensions map[string]struct{}
+}
+
+// NewLinkExtractor 编译提取规则，正则无效时返回错误
+func NewLinkExtractor(config LinkExtractorConfig) (*LinkExtractor, error) {
+	le := &LinkExtractor{
+		config:     config,
+		tags:       make(map[string]struct{}),
+		extensions: make(map[string]struct{}),
+	}
+
+	var err error
+	if le.allow, err = compileAll(config.Allow); err != nil {
+		return nil, fmt.Errorf("allow 规则无效: %w", err)
+	}
+	if le.deny, err = compileAll(config.Deny); err != nil {
+		return nil, fmt.Errorf("deny 规则无效: %w", err)
+	}
+
+	tags := config.Tags
+	if len(tags) == 0 {
+		tags = []string{"a", "area"}
+	}
+	for _, tag := range tags {
+		le.tags[strings.ToLower(tag)] = struct{}{}
+	}
+
+	le.attrs = config.Attrs
+	if len(le.attrs) == 0 {
+		le.attrs = []string{"href"}
+	}
+
+	extensions := config.DenyExtensions
+	if extensions == nil {
+		extensions = DefaultDenyExtensions
+	}
+	for _, ext := range extensions {
+		le.extensions["."+strings.ToLower(strings.TrimPrefix(ext, "."))] = struct{}{}
+	}
+	return le, nil
+}
+
+// compileAll 编译一组正则，经过 httpc 的共享缓存，每个响应重建提取器时不会重复编译
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := httpc.CompileRegex(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// Extract 提取响应中符合规则的链接，按文档顺序返回
+func (le *LinkExtractor) Extract(resp *httpc.Response) ([]Link, error) {
+	base, err := resp.BaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := le.regions(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]Link, 0)
+	seen := make(map[string]struct{})
+	visited := make(map[*html.Node]struct{}) // 区域可能重叠，同一个节点只处理一次
+	for _, region := range regions {
+		for _, node := range elements(region) {
+			if _, ok := visited[node]; ok {
+				continue
+			}
+			visited[node] = struct{}{}
+
+			if _, ok := le.tags[node.Data]; !ok {
+				continue
+			}
+			for _, attr := range le.attrs {
+				link, ok := le.buildLink(base, node, attr)
+				if !ok {
+					continue
+				}
+				if !le.config.AllowDuplicates {
+					if _, dup := seen[link.URL]; dup {
+						continue
+					}
+					seen[link.URL] = struct{}{}
+				}
+				links = append(links, link)
+			}
+		}
+	}
+	return links, nil
+}
+
+// regions 返回提取区域的根节点
+func (le *LinkExtractor) regions(resp *httpc.Response) ([]*html.Node, error) {
+	if len(le.config.RestrictCSS) == 0 && len(le.config.RestrictXPath) == 0 {
+		root := resp.Selector()
+		if root.Node() == nil {
+			// XML 文档没有 HTML 链接
+			return nil, resp.Error()
+		}
+		return []*html.Node{root.Node()}, nil
+	}
+
+	var lists []httpc.SelectorList
+	for _, css := range le.config.RestrictCSS {
+		lists = append(lists, resp.CSS(css))
+	}
+	for _, xp := range le.config.RestrictXPath {
+		lists = append(lists, resp.XPath(xp))
+	}
+
+	var nodes []*html.Node
+	for _, list := range lists {
+		if err := list.Error(); err != nil {
+			return nil, err
+		}
+		for _, sel := range list.All() {
+			if node := sel.Node(); node != nil {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// buildLink 根据节点属性构造链接并执行过滤
+func (le *LinkExtractor) buildLink(base *url.URL, node *html.Node, attrName string) (Link, bool) {
+	value, ok := attr(node, attrName)
+	if !ok {
+		return Link{}, false
+	}
+	value = strings.TrimSpace(value)
+	if le.config.ProcessValue != nil {
+		value = le.config.ProcessValue(value)
+	}
+	if value == "" {
+		return Link{}, false
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil {
+		return Link{}, false
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Link{}, false
+	}
+
+	fragment := u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+	if le.config.Canonicalize {
+		canonicalize(u)
+	}
+	abs := u.String()
+
+	if !le.allowed(u, abs) {
+		return Link{}, false
+	}
+
+	rel, _ := attr(node, "rel")
+	return Link{
+		URL:      abs,
+		Text:     strings.TrimSpace(innerText(node)),
+		Fragment: fragment,
+		NoFollow: hasToken(rel, "nofollow"),
+	}, true
+}
+
+// allowed 执行域名、扩展名和正则过滤
+func (le *LinkExtractor) allowed(u *url.URL, abs string) bool {
+	host := strings.ToLower(u.Hostname())
+	if len(le.config.AllowDomains) > 0 && !matchDomain(host, le.config.AllowDomains) {
+		return false
+	}
+	if matchDomain(host, le.config.DenyDomains) {
+		return false
+	}
+
+	if _, ok := le.extensions[strings.ToLower(path.Ext(u.Path))]; ok {
+		return false
+	}
+
+	for _, re := range le.deny {
+		if re.MatchString(abs) {
+			return false
+		}
+	}
+	if len(le.allow) == 0 {
+		return true
+	}
+	for _, re := range le.allow {
+		if re.MatchString(abs) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain 判断 host 是否为列表中的域名或其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(d, "."))
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalize 规范化 URL：小写协议和主机、去掉默认端口、按键排序查询参数
+func canonicalize(u *url.URL) {
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = host
+	} else {
+		u.Host = host + ":" + port
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		pairs := strings.Split(u.RawQuery, "&")
+		kept := pairs[:0]
+		for _, p := range pairs {
+			if p != "" {
+				kept = append(kept, p)
+			}
+		}
+		sort.SliceStable(kept, func(i, j int) bool {
+			ki, _, _ := strings.Cut(kept[i], "=")
+			kj, _, _ := strings.Cut(kept[j], "=")
+			return ki < kj
+		})
+		u.RawQuery = strings.Join(kept, "&")
+	}
+}
+
+// elements 返回 n 自身及全部后代元素，按文档顺序
+func elements(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	var walk func(*html.Node)
+	walk = func(cur *html.Node) {
+		if cur.Type == html.ElementNode {
+			nodes = append(nodes, cur)
+		}
+		for c := cur.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return nodes
+}
+
+// attr 读取属性值
+func attr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// hasToken 判断空格分隔的属性值中是否包含 token
+func hasToken(value, token string) bool {
+	for _, f := range strings.Fields(strings.ToLower(value)) {
+		if f == token {
+			return true
+		}
+	}
+	return false
+}
+
+// innerText 返回节点内的文本，连续空白压缩为一个空格
+func innerText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(cur *html.Node) {
+		switch cur.Type {
+		case html.TextNode:
+			b.WriteString(cur.Data)
+		case html.ElementNode:
+			if cur.Data == "script" || cur.Data == "style" {
+				return
+			}
+		}
+		for c := cur.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
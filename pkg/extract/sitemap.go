@@ -0,0 +1,203 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 站点地图类型
+const (
+	SitemapURLSet = "urlset"       // 页面列表
+	SitemapIndex  = "sitemapindex" // 子站点地图索引
+)
+
+// maxSitemapSize 解压后站点地图的最大字节数（协议规定 50MB）
+const maxSitemapSize = 50 << 20
+
+// AlternateLink 页面的其他语言版本（<xhtml:link rel="alternate" hreflang="...">）
+type AlternateLink struct {
+	Lang string
+	URL  string
+}
+
+// SitemapEntry 站点地图中的一条记录，urlset 为页面，sitemapindex 为子站点地图
+type SitemapEntry struct {
+	Loc        string
+	LastMod    time.Time // 未提供或无法解析时为零值
+	ChangeFreq string
+	Priority   float64 // 未提供时为 0
+	Alternates []AlternateLink
+}
+
+// Sitemap 解析后的站点地图
+type Sitemap struct {
+	Type    string // SitemapURLSet 或 SitemapIndex
+	Entries []SitemapEntry
+}
+
+// xmlSitemapEntry <url>/<sitemap> 元素
+type xmlSitemapEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+	Priority   string `xml:"priority"`
+	Links      []struct {
+		Rel      string `xml:"rel,attr"`
+		HrefLang string `xml:"hreflang,attr"`
+		Href     string `xml:"href,attr"`
+	} `xml:"link"`
+}
+
+// xmlSitemap 根元素，urlset 与 sitemapindex 共用
+type xmlSitemap struct {
+	XMLName  xml.Name
+	URLs     []xmlSitemapEntry `xml:"url"`
+	Sitemaps []xmlSitemapEntry `xml:"sitemap"`
+}
+
+// ParseSitemap 解析 XML 站点地图或纯文本站点地图（每行一个 URL），
+// 自动识别并解压 gzip 内容（.xml.gz）
+func ParseSitemap(body []byte) (*Sitemap, error) {
+	body, err := gunzipIfNeeded(body)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return nil, errors.New("sitemap: 内容为空")
+	}
+	if trimmed[0] != '<' {
+		return parseTextSitemap(trimmed), nil
+	}
+
+	var doc xmlSitemap
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("sitemap: 解析失败: %w", err)
+	}
+
+	sm := &Sitemap{}
+	var entries []xmlSitemapEntry
+	switch strings.ToLower(doc.XMLName.Local) {
+	case SitemapURLSet:
+		sm.Type = SitemapURLSet
+		entries = doc.URLs
+	case SitemapIndex:
+		sm.Type = SitemapIndex
+		entries = doc.Sitemaps
+	default:
+		return nil, fmt.Errorf("sitemap: 未知的根元素 <%s>", doc.XMLName.Local)
+	}
+
+	for _, e := range entries {
+		loc := strings.TrimSpace(e.Loc)
+		if loc == "" {
+			continue
+		}
+		entry := SitemapEntry{
+			Loc:        loc,
+			LastMod:    parseW3CDate(e.LastMod),
+			ChangeFreq: strings.TrimSpace(e.ChangeFreq),
+		}
+		if p, err := strconv.ParseFloat(strings.TrimSpace(e.Priority), 64); err == nil {
+			entry.Priority = p
+		}
+		for _, link := range e.Links {
+			if strings.EqualFold(link.Rel, "alternate") && link.Href != "" {
+				entry.Alternates = append(entry.Alternates, AlternateLink{
+					Lang: link.HrefLang,
+					URL:  strings.TrimSpace(link.Href),
+				})
+			}
+		}
+		sm.Entries = append(sm.Entries, entry)
+	}
+	return sm, nil
+}
+
+// gunzipIfNeeded 按 gzip 魔数判断并解压，限制解压后的大小
+func gunzipIfNeeded(body []byte) ([]byte, error) {
+	if len(body) < 2 || body[0] != 0x1f || body[1] != 0x8b {
+		return body, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: gzip 解压失败: %w", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxSitemapSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: gzip 解压失败: %w", err)
+	}
+	if len(data) > maxSitemapSize {
+		return nil, errors.New("sitemap: 解压后超过 50MB")
+	}
+	return data, nil
+}
+
+// parseTextSitemap 解析纯文本站点地图
+func parseTextSitemap(body []byte) *Sitemap {
+	sm := &Sitemap{Type: SitemapURLSet}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			sm.Entries = append(sm.Entries, SitemapEntry{Loc: line})
+		}
+	}
+	return sm
+}
+
+// w3cLayouts lastmod 允许的 W3C Datetime 格式
+var w3cLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parseW3CDate 解析 lastmod，失败时返回零值
+func parseW3CDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range w3cLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// SitemapsFromRobots 从 robots.txt 中读取 Sitemap: 声明的地址
+func SitemapsFromRobots(body []byte) []string {
+	var urls []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "sitemap") {
+			continue
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			urls = append(urls, value)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,224 @@
+package httpc
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/antchfx/xpath"
+	"github.com/golang/groupcache/lru"
+)
+
+// DefaultCompileCacheSize CSS、XPath、正则、JSONPath 编译缓存各自默认保留的条目数
+const DefaultCompileCacheSize = 1024
+
+// compileCache 并发安全的有界 LRU 缓存，以表达式为键保存编译结果（包括编译错误），
+// 在所有响应之间共享
+type compileCache struct {
+	mu       sync.Mutex
+	cache    *lru.Cache
+	disabled bool
+}
+
+// compiled 缓存条目
+type compiled struct {
+	value any
+	err   error
+}
+
+func newCompileCache(size int) *compileCache {
+	return &compileCache{cache: lru.New(size)}
+}
+
+// get 返回 key 的编译结果，未命中时调用 compile 并缓存
+func (c *compileCache) get(key string, compile func() (any, error)) (any, error) {
+	c.mu.Lock()
+	if c.disabled {
+		c.mu.Unlock()
+		return compile()
+	}
+	if v, ok := c.cache.Get(key); ok {
+		c.mu.Unlock()
+		entry := v.(compiled)
+		return entry.value, entry.err
+	}
+	c.mu.Unlock()
+
+	// 在锁外编译，并发未命中时可能重复编译，结果相同
+	value, err := compile()
+	c.mu.Lock()
+	if !c.disabled {
+		c.cache.Add(key, compiled{value: value, err: err})
+	}
+	c.mu.Unlock()
+	return value, err
+}
+
+// resize 调整容量，size <= 0 时关闭缓存并清空
+func (c *compileCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disabled = size <= 0
+	if c.disabled {
+		c.cache.Clear()
+		return
+	}
+	c.cache.MaxEntries = size
+	for c.cache.Len() > size {
+		c.cache.RemoveOldest()
+	}
+}
+
+// 各类表达式的编译缓存
+var (
+	cssCache      = newCompileCache(DefaultCompileCacheSize)
+	xpathCache    = newCompileCache(DefaultCompileCacheSize)
+	regexCache    = newCompileCache(DefaultCompileCacheSize)
+	jsonPathCache = newCompileCache(DefaultCompileCacheSize)
+)
+
+// SetCompileCacheSize 设置每种编译缓存的容量，size <= 0 时关闭缓存（每次调用都重新编译）
+func SetCompileCacheSize(size int) {
+	for _, c := range []*compileCache{cssCache, xpathCache, regexCache, jsonPathCache} {
+		c.resize(size)
+	}
+}
+
+// ClearCompileCache 清空全部编译缓存
+func ClearCompileCache() {
+	for _, c := range []*compileCache{cssCache, xpathCache, regexCache, jsonPathCache} {
+		c.mu.Lock()
+		c.cache.Clear()
+		c.mu.Unlock()
+	}
+}
+
+// compileCSS 编译 CSS 选择器（支持 ::text、::attr() 伪元素），结果被缓存
+func compileCSS(selector string) (*cssQuery, error) {
+	v, err := cssCache.get(selector, func() (any, error) {
+		return compileCSSQuery(selector)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*cssQuery), nil
+}
+
+// xpathProgram 编译后的 XPath 表达式。xpath.Expr 在求值时会修改内部状态，
+// 不能并发使用，因此每次求值从池中取出一个独占的副本
+type xpathProgram struct {
+	pool sync.Pool
+}
+
+// acquire 取出一个可独占使用的表达式，用完后调用 release 放回
+func (p *xpathProgram) acquire() *xpath.Expr {
+	return p.pool.Get().(*xpath.Expr)
+}
+
+func (p *xpathProgram) release(exp *xpath.Expr) {
+	p.pool.Put(exp)
+}
+
+// compileXPath 编译 XPath 表达式，ns 为前缀到命名空间 URI 的映射（可为 nil），结果被缓存
+func compileXPath(expr string, ns map[string]string) (*xpathProgram, error) {
+	key := expr
+	if len(ns) > 0 {
+		prefixes := make([]string, 0, len(ns))
+		for prefix := range ns {
+			prefixes = append(prefixes, prefix)
+		}
+		sort.Strings(prefixes)
+		var b strings.Builder
+		b.WriteString(expr)
+		for _, prefix := range prefixes {
+			b.WriteString("\x00" + prefix + "=" + ns[prefix])
+		}
+		key = b.String()
+	}
+
+	v, err := xpathCache.get(key, func() (any, error) {
+		exp, err := xpath.CompileWithNS(expr, ns)
+		if err != nil {
+			return nil, err
+		}
+		p := &xpathProgram{}
+		p.pool.New = func() any {
+			// 表达式已经编译成功过一次，不会再出错
+			exp, _ := xpath.CompileWithNS(expr, ns)
+			return exp
+		}
+		p.pool.Put(exp)
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*xpathProgram), nil
+}
+
+// CompileRegex 编译正则表达式，结果在全局缓存中共享。regexp.Regexp 可并发使用
+func CompileRegex(pattern string) (*regexp.Regexp, error) {
+	v, err := regexCache.get(pattern, func() (any, error) {
+		return regexp.Compile(pattern)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*regexp.Regexp), nil
+}
+
+// MustCompileRegex 同 CompileRegex，表达式无效时 panic，适合在构造爬虫时预编译
+func MustCompileRegex(pattern string) *regexp.Regexp {
+	re, err := CompileRegex(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("httpc: invalid regex %q: %v", pattern, err))
+	}
+	return re
+}
+
+// CompileCSS 检查 CSS 选择器并预先编译到缓存中
+func CompileCSS(selector string) error {
+	if _, err := compileCSS(selector); err != nil {
+		return fmt.Errorf("invalid CSS selector %q: %v", selector, err)
+	}
+	return nil
+}
+
+// MustCompileCSS 预编译 CSS 选择器并原样返回，选择器无效时 panic，
+// 用于在构造爬虫时尽早发现错误：
+//
+//	var titleCSS = httpc.MustCompileCSS("h1.title::text")
+//	resp.CSS(titleCSS)
+func MustCompileCSS(selector string) string {
+	if err := CompileCSS(selector); err != nil {
+		panic("httpc: " + err.Error())
+	}
+	return selector
+}
+
+// CompileXPath 检查 XPath 表达式并预先编译到缓存中
+func CompileXPath(expr string) error {
+	if _, err := compileXPath(expr, nil); err != nil {
+		return fmt.Errorf("invalid XPath expression %q: %v", expr, err)
+	}
+	return nil
+}
+
+// MustCompileXPath 预编译 XPath 表达式并原样返回，表达式无效时 panic，用法同 MustCompileCSS
+func MustCompileXPath(expr string) string {
+	if err := CompileXPath(expr); err != nil {
+		panic("httpc: " + err.Error())
+	}
+	return expr
+}
+
+// MustCompileJSONPath 同 CompileJSONPath，表达式无效时 panic
+func MustCompileJSONPath(expr string) *JSONPath {
+	jp, err := CompileJSONPath(expr)
+	if err != nil {
+		panic("httpc: " + err.Error())
+	}
+	return jp
+}
@@ -0,0 +1,176 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// FollowConfig 控制 Follow / FollowAll 生成请求的方式
+type FollowConfig struct {
+	InheritMeta bool     // 继承当前请求的全部 Meta
+	MetaKeys    []string // 只继承指定的 Meta 键（InheritMeta 为 false 时生效）
+	NoReferer   bool     // 不设置 Referer 请求头
+}
+
+// BaseURL 返回解析相对链接时使用的基准地址：页面中的 <base href> 优先，否则为响应 URL。
+func (r *Response) BaseURL() (*url.URL, error) {
+	base, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	if href := r.CSS("base[href]").Attr("href"); href != "" {
+		if ref, err := url.Parse(strings.TrimSpace(href)); err == nil {
+			return base.ResolveReference(ref), nil
+		}
+	}
+	return base, nil
+}
+
+// URLJoin 将相对链接解析为绝对地址，遵循页面中的 <base href>。
+func (r *Response) URLJoin(href string) (string, error) {
+	base, err := r.BaseURL()
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// Follow 根据链接创建新请求。target 可以是：
+//
+//	string         相对或绝对链接
+//	*Selector      <a>/<link>/<area> 取 href，其他元素取 src；字符串值选择器（如 ::attr(href)）取值本身；
+//	               XML 节点取 href 属性，没有时取文本
+//	SelectorList   使用第一个选择器
+//
+// 生成的请求设置 Referer 为当前响应地址，并按 config 继承 Meta。
+func (r *Response) Follow(target any, cb ParseFunc, config ...FollowConfig) (*Request, error) {
+	var href string
+	switch t := target.(type) {
+	case string:
+		href = t
+	case *Selector:
+		href = selectorLink(t)
+	case SelectorList:
+		if t.Error() != nil {
+			return nil, t.Error()
+		}
+		if t.Length() == 0 {
+			return nil, errors.New("follow: 选择器列表为空")
+		}
+		href = selectorLink(t.All()[0])
+	default:
+		return nil, fmt.Errorf("follow: 不支持的类型 %T", target)
+	}
+
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return nil, errors.New("follow: 链接为空")
+	}
+	abs, err := r.URLJoin(href)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTTPURL(abs) {
+		return nil, fmt.Errorf("follow: 不支持的链接 %s", abs)
+	}
+	return r.newFollowRequest(abs, cb, config...), nil
+}
+
+// FollowAll 对 CSS 选择器匹配到的每个链接创建请求，跳过空链接和非 http(s) 链接
+// （如 javascript:、mailto:）。
+func (r *Response) FollowAll(selector string, cb ParseFunc, config ...FollowConfig) ([]*Request, error) {
+	list := r.CSS(selector)
+	if list.Error() != nil {
+		return nil, list.Error()
+	}
+	base, err := r.BaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]*Request, 0, list.Length())
+	for _, sel := range list.All() {
+		href := strings.TrimSpace(selectorLink(sel))
+		if href == "" {
+			continue
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		abs := base.ResolveReference(ref).String()
+		if !isHTTPURL(abs) {
+			continue
+		}
+		requests = append(requests, r.newFollowRequest(abs, cb, config...))
+	}
+	return requests, nil
+}
+
+// newFollowRequest 创建跟进请求，处理 Referer 和 Meta 继承
+func (r *Response) newFollowRequest(abs string, cb ParseFunc, config ...FollowConfig) *Request {
+	cfg := FollowConfig{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	req := New(abs).WithCallback(cb)
+	if !cfg.NoReferer {
+		req.WithHeader("Referer", r.URL)
+	}
+	if cfg.InheritMeta {
+		for k, v := range r.Request.Meta {
+			req.Meta[k] = v
+		}
+	} else {
+		for _, k := range cfg.MetaKeys {
+			if v, ok := r.Request.Meta[k]; ok {
+				req.Meta[k] = v
+			}
+		}
+	}
+	return req
+}
+
+// selectorLink 从选择器中取出链接
+func selectorLink(s *Selector) string {
+	if s.IsValue() {
+		return s.Get()
+	}
+	if xn := s.XMLNode(); xn != nil {
+		// Atom 的 <link href="..."/>，RSS 的 <link>...</link>
+		if href := xn.SelectAttr("href"); href != "" {
+			return href
+		}
+		return s.Text()
+	}
+	node := s.Node()
+	if node == nil || node.Type != html.ElementNode {
+		return ""
+	}
+	switch node.Data {
+	case "a", "link", "area":
+		return s.Attr("href")
+	}
+	if src := s.Attr("src"); src != "" {
+		return src
+	}
+	return s.Attr("href")
+}
+
+// isHTTPURL 判断是否为 http/https 地址
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,532 @@
+package httpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// FormSelector 定位页面中的表单。CSS、XPath、Name 按顺序取第一个非空的条件，
+// 都为空时在全部表单中选择；Index 为匹配结果中的第几个（从 0 开始）。
+// 匹配到的元素不是 <form> 时使用其所在的表单
+type FormSelector struct {
+	CSS   string
+	XPath string
+	Name  string // 表单的 name 或 id 属性
+	Index int
+}
+
+// FormFile 以 multipart/form-data 上传的文件
+type FormFile struct {
+	Filename    string
+	ContentType string // 为空时为 application/octet-stream
+	Content     []byte
+}
+
+// FormOverrides 覆盖表单中的默认值与提交方式
+type FormOverrides struct {
+	Data     map[string]string   // 覆盖同名字段的全部值，不存在时追加
+	Remove   []string            // 删除的字段
+	Files    map[string]FormFile // 上传的文件，设置后使用 multipart/form-data
+	Click    string              // 点击的提交按钮，按 name、id、value 或按钮文本匹配；为空时点击第一个提交按钮
+	NoClick  bool                // 不点击任何按钮（按钮的 name/value 不会提交）
+	Method   string              // 覆盖表单的 method，只支持 GET 与 POST
+	Action   string              // 覆盖表单的 action
+	Callback ParseFunc
+}
+
+// formField 表单数据中的一个字段，保持页面中的顺序
+type formField struct {
+	name  string
+	value string
+	file  *FormFile
+}
+
+// FormRequestFromResponse 根据页面中的表单创建请求：收集 input、select、textarea 的默认值
+// （包括隐藏字段中的 CSRF token），加上被点击的提交按钮，按 overrides 修改后生成
+// GET、application/x-www-form-urlencoded 或 multipart/form-data 的 POST 请求，其他提交方法返回错误。
+// 字段按表单的 accept-charset 或页面编码进行编码，请求的 Referer 为当前响应地址
+func FormRequestFromResponse(resp *Response, sel FormSelector, overrides ...FormOverrides) (*Request, error) {
+	var ov FormOverrides
+	if len(overrides) > 0 {
+		ov = overrides[0]
+	}
+
+	form, err := findForm(resp, sel)
+	if err != nil {
+		return nil, err
+	}
+	fields, submitter, err := formFields(form, resp.Selector().Node(), ov)
+	if err != nil {
+		return nil, err
+	}
+	fields = applyOverrides(fields, ov)
+
+	// 提交按钮的 formaction / formmethod / formenctype 优先于表单属性
+	formAttr := func(name string) string {
+		if submitter != nil {
+			if v, ok := attrValue(submitter, "form"+name); ok {
+				return strings.TrimSpace(v)
+			}
+		}
+		v, _ := attrValue(form, name)
+		return strings.TrimSpace(v)
+	}
+
+	method := strings.ToUpper(formAttr("method"))
+	if ov.Method != "" {
+		method = strings.ToUpper(strings.TrimSpace(ov.Method))
+	}
+	switch method {
+	case "":
+		method = "GET"
+	case "GET", "POST":
+	default:
+		// 表单只能以 GET 或 POST 提交，其他方法（包括 dialog）不悄悄改为 GET
+		return nil, fmt.Errorf("form: 不支持的提交方法 %q", method)
+	}
+
+	action := formAttr("action")
+	if ov.Action != "" {
+		action = ov.Action
+	}
+	target := resp.URL
+	if action != "" {
+		if target, err = resp.URLJoin(action); err != nil {
+			return nil, err
+		}
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+
+	encode := formEncoder(form, resp)
+	enctype := strings.ToLower(formAttr("enctype"))
+	if len(ov.Files) > 0 {
+		enctype = "multipart/form-data"
+	}
+
+	req := New("").WithCallback(ov.Callback).WithHeader("Referer", resp.URL)
+	switch {
+	case method == "GET":
+		u.RawQuery = urlEncodeFields(fields, encode)
+	case enctype == "multipart/form-data":
+		body, contentType, err := multipartFields(fields, encode)
+		if err != nil {
+			return nil, err
+		}
+		req.WithMethod("POST").WithBody(body).WithHeader("Content-Type", contentType)
+	case enctype == "text/plain":
+		var b strings.Builder
+		for _, f := range fields {
+			if f.file == nil {
+				b.WriteString(encode(f.name) + "=" + encode(f.value) + "\r\n")
+			}
+		}
+		req.WithMethod("POST").WithBody([]byte(b.String())).WithHeader("Content-Type", "text/plain")
+	default:
+		req.WithMethod("POST").
+			WithBody([]byte(urlEncodeFields(fields, encode))).
+			WithHeader("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.URL = u.String()
+	return req, nil
+}
+
+// findForm 按 FormSelector 查找表单节点
+func findForm(resp *Response, sel FormSelector) (*html.Node, error) {
+	var list SelectorList
+	switch {
+	case sel.CSS != "":
+		list = resp.CSS(sel.CSS)
+	case sel.XPath != "":
+		list = resp.XPath(sel.XPath)
+	case sel.Name != "":
+		list = resp.CSS("form").Filter(func(s *Selector) bool {
+			return s.Attr("name") == sel.Name || s.Attr("id") == sel.Name
+		})
+	default:
+		list = resp.CSS("form")
+	}
+	if err := list.Error(); err != nil {
+		return nil, err
+	}
+
+	var forms []*html.Node
+	for _, s := range list.All() {
+		for n := s.Node(); n != nil; n = n.Parent {
+			if n.Type == html.ElementNode && n.Data == "form" {
+				forms = append(forms, n)
+				break
+			}
+		}
+	}
+	if len(forms) == 0 {
+		return nil, errors.New("form: 没有找到表单")
+	}
+	if sel.Index < 0 || sel.Index >= len(forms) {
+		return nil, fmt.Errorf("form: 表单下标 %d 超出范围（共 %d 个）", sel.Index, len(forms))
+	}
+	return forms[sel.Index], nil
+}
+
+// formFields 按 HTML 规范构造表单数据集，返回字段与被点击的提交按钮
+func formFields(form, root *html.Node, ov FormOverrides) ([]formField, *html.Node, error) {
+	var submitter *html.Node
+	if !ov.NoClick {
+		var err error
+		if submitter, err = clickedButton(form, root, ov.Click); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var fields []formField
+	for _, n := range formElements(form, root) {
+		name, _ := attrValue(n, "name")
+		if name == "" || isDisabledControl(n) {
+			continue
+		}
+		switch n.Data {
+		case "input":
+			value, hasValue := attrValue(n, "value")
+			switch inputType(n) {
+			case "checkbox", "radio":
+				if _, checked := attrValue(n, "checked"); checked {
+					if !hasValue {
+						value = "on"
+					}
+					fields = append(fields, formField{name: name, value: value})
+				}
+			case "submit", "button", "reset":
+				if n == submitter {
+					fields = append(fields, formField{name: name, value: value})
+				}
+			case "image":
+				if n == submitter {
+					fields = append(fields, formField{name: name + ".x", value: "0"}, formField{name: name + ".y", value: "0"})
+				}
+			case "file":
+				fields = append(fields, formField{name: name, file: &FormFile{}})
+			default:
+				fields = append(fields, formField{name: name, value: value})
+			}
+		case "button":
+			if n == submitter {
+				value, _ := attrValue(n, "value")
+				fields = append(fields, formField{name: name, value: value})
+			}
+		case "select":
+			for _, v := range selectedOptions(n) {
+				fields = append(fields, formField{name: name, value: v})
+			}
+		case "textarea":
+			// 解析器已去掉开头的换行
+			fields = append(fields, formField{name: name, value: textContent(n)})
+		}
+	}
+	return fields, submitter, nil
+}
+
+// formElements 返回与表单关联的控件：表单内没有 form 属性的控件，以及 form 属性指向该表单 id 的控件
+func formElements(form, root *html.Node) []*html.Node {
+	id, _ := attrValue(form, "id")
+	scope := form
+	if id != "" && root != nil {
+		scope = root
+	}
+
+	var res []*html.Node
+	for _, n := range descendantElements(scope) {
+		switch n.Data {
+		case "input", "button", "select", "textarea":
+		default:
+			continue
+		}
+		if owner, ok := attrValue(n, "form"); ok {
+			if id != "" && owner == id {
+				res = append(res, n)
+			}
+			continue
+		}
+		if isInside(n, form) {
+			res = append(res, n)
+		}
+	}
+	return res
+}
+
+// clickedButton 返回被点击的提交按钮，click 为空时返回第一个可用的提交按钮（可能为 nil）
+func clickedButton(form, root *html.Node, click string) (*html.Node, error) {
+	for _, n := range formElements(form, root) {
+		if !isSubmitButton(n) || isDisabledControl(n) {
+			continue
+		}
+		if click == "" {
+			return n, nil
+		}
+		name, _ := attrValue(n, "name")
+		id, _ := attrValue(n, "id")
+		value, _ := attrValue(n, "value")
+		if click == name || click == id || click == value || (n.Data == "button" && click == collapseSpace(textContent(n))) {
+			return n, nil
+		}
+	}
+	if click != "" {
+		return nil, fmt.Errorf("form: 没有找到提交按钮 %q", click)
+	}
+	return nil, nil
+}
+
+// applyOverrides 删除、覆盖与追加字段，新字段按名称排序追加在末尾
+func applyOverrides(fields []formField, ov FormOverrides) []formField {
+	drop := make(map[string]bool, len(ov.Remove)+len(ov.Data)+len(ov.Files))
+	for _, name := range ov.Remove {
+		drop[name] = true
+	}
+	for name := range ov.Data {
+		drop[name] = true
+	}
+	for name := range ov.Files {
+		drop[name] = true
+	}
+
+	var res []formField
+	placed := make(map[string]bool)
+	for _, f := range fields {
+		if !drop[f.name] {
+			res = append(res, f)
+			continue
+		}
+		// 覆盖的值放在原字段第一次出现的位置
+		if placed[f.name] {
+			continue
+		}
+		placed[f.name] = true
+		if v, ok := ov.Data[f.name]; ok {
+			res = append(res, formField{name: f.name, value: v})
+		} else if file, ok := ov.Files[f.name]; ok {
+			res = append(res, formField{name: f.name, file: &file})
+		}
+	}
+
+	var extra []formField
+	for name, v := range ov.Data {
+		if !placed[name] {
+			extra = append(extra, formField{name: name, value: v})
+		}
+	}
+	for name, file := range ov.Files {
+		if !placed[name] {
+			extra = append(extra, formField{name: name, file: &file})
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].name < extra[j].name })
+	return append(res, extra...)
+}
+
+// urlEncodeFields 按 application/x-www-form-urlencoded 编码字段，文件字段只提交文件名
+func urlEncodeFields(fields []formField, encode func(string) string) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		value := f.value
+		if f.file != nil {
+			value = f.file.Filename
+		}
+		parts = append(parts, url.QueryEscape(encode(f.name))+"="+url.QueryEscape(encode(value)))
+	}
+	return strings.Join(parts, "&")
+}
+
+// multipartFields 按 multipart/form-data 编码字段，返回请求体与 Content-Type
+func multipartFields(fields []formField, encode func(string) string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, f := range fields {
+		if f.file == nil {
+			if err := w.WriteField(encode(f.name), encode(f.value)); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		contentType := f.file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(encode(f.name)), escapeQuotes(encode(f.file.Filename))))
+		h.Set("Content-Type", contentType)
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(f.file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// escapeQuotes 转义 Content-Disposition 中的引号与反斜杠
+func escapeQuotes(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// formEncoder 返回将字段转换为提交编码的函数：优先使用 accept-charset 中第一个可识别的编码，
+// 否则使用页面编码；无法编码的字符保留 UTF-8
+func formEncoder(form *html.Node, resp *Response) func(string) string {
+	names := []string{resp.Encoding()}
+	if v, ok := attrValue(form, "accept-charset"); ok {
+		names = append(strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' }), names...)
+	}
+	for _, name := range names {
+		enc, canonical := charset.Lookup(name)
+		if enc == nil {
+			continue
+		}
+		if canonical == "utf-8" {
+			break
+		}
+		encoder := enc.NewEncoder()
+		return func(s string) string {
+			if out, err := encoder.String(s); err == nil {
+				return out
+			}
+			return s
+		}
+	}
+	return func(s string) string { return s }
+}
+
+// inputType 返回 <input> 的类型，默认为 text
+func inputType(n *html.Node) string {
+	t, _ := attrValue(n, "type")
+	t = strings.ToLower(strings.TrimSpace(t))
+	if t == "" {
+		return "text"
+	}
+	return t
+}
+
+// isSubmitButton 判断控件是否为提交按钮
+func isSubmitButton(n *html.Node) bool {
+	switch n.Data {
+	case "input":
+		t := inputType(n)
+		return t == "submit" || t == "image"
+	case "button":
+		t, _ := attrValue(n, "type")
+		t = strings.ToLower(strings.TrimSpace(t))
+		return t == "" || t == "submit"
+	}
+	return false
+}
+
+// isDisabledControl 判断控件是否被禁用（包括位于禁用的 <fieldset> 中，第一个 <legend> 除外）
+func isDisabledControl(n *html.Node) bool {
+	if _, ok := attrValue(n, "disabled"); ok {
+		return true
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type != html.ElementNode || p.Data != "fieldset" {
+			continue
+		}
+		if _, ok := attrValue(p, "disabled"); !ok {
+			continue
+		}
+		if legend := firstChildElement(p, "legend"); legend == nil || !isInside(n, legend) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedOptions 返回 <select> 提交的值：选中的选项，单选且没有选中项时为第一个可用选项
+func selectedOptions(sel *html.Node) []string {
+	_, multiple := attrValue(sel, "multiple")
+	var values []string
+	var first *html.Node
+	for _, opt := range descendantElements(sel) {
+		if opt.Data != "option" {
+			continue
+		}
+		if _, disabled := attrValue(opt, "disabled"); disabled {
+			continue
+		}
+		if first == nil {
+			first = opt
+		}
+		if _, selected := attrValue(opt, "selected"); selected {
+			values = append(values, optionValue(opt))
+			if !multiple {
+				values = values[len(values)-1:]
+			}
+		}
+	}
+	if len(values) == 0 && !multiple && first != nil {
+		values = append(values, optionValue(first))
+	}
+	return values
+}
+
+// optionValue 返回选项的 value 属性，没有时为选项文本
+func optionValue(opt *html.Node) string {
+	if v, ok := attrValue(opt, "value"); ok {
+		return v
+	}
+	return collapseSpace(textContent(opt))
+}
+
+// textContent 返回节点内的全部文本，不做空白处理
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	for _, c := range descendantNodes(n) {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+	}
+	return b.String()
+}
+
+// descendantNodes 返回 n 的所有后代节点
+func descendantNodes(n *html.Node) []*html.Node {
+	var res []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		res = append(res, c)
+		res = append(res, descendantNodes(c)...)
+	}
+	return res
+}
+
+// firstChildElement 返回第一个指定标签的子元素
+func firstChildElement(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// isInside 判断 n 是否为 ancestor 的后代
+func isInside(n, ancestor *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == ancestor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,314 @@
+package httpc
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+const formPageURL = "http://example.com/dir/page.html"
+
+// formResponse 以 HTML 片段构造响应
+func formResponse(body string, headers ...string) *Response {
+	h := map[string]string{"Content-Type": "text/html; charset=utf-8"}
+	if len(headers) > 0 {
+		h["Content-Type"] = headers[0]
+	}
+	return NewResponse(formPageURL, 200, h, []byte(body), New(formPageURL), "HTTP/1.1")
+}
+
+// formSummary 将请求概括为 "METHOD URL" 加请求体，便于比较
+func formSummary(req *Request) string {
+	s := req.Method + " " + req.URL
+	if len(req.Body) > 0 {
+		s += "\n" + string(req.Body)
+	}
+	return s
+}
+
+func TestFormRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		sel  FormSelector
+		ov   FormOverrides
+		want string
+	}{
+		{
+			name: "get with relative action",
+			html: `<form action="search?x=1#top"><input name="q" value="go lang"><input type="submit" name="s" value="Go"></form>`,
+			want: "GET http://example.com/dir/search?q=go+lang&s=Go",
+		},
+		{
+			name: "no action submits to page",
+			html: `<form><input name="q" value="1"></form>`,
+			want: "GET http://example.com/dir/page.html?q=1",
+		},
+		{
+			name: "base href",
+			html: `<head><base href="http://other.com/a/"></head><form action="b"><input name="q" value="1"></form>`,
+			want: "GET http://other.com/a/b?q=1",
+		},
+		{
+			name: "post hidden checkbox radio",
+			html: `<form method="post" action="/login">
+				<input type="hidden" name="csrf" value="t0k">
+				<input type="checkbox" name="remember" checked>
+				<input type="checkbox" name="news" value="yes">
+				<input type="radio" name="plan" value="free">
+				<input type="radio" name="plan" value="pro" checked>
+				<input name="user" value="bob">
+				<input value="unnamed">
+				<input type="reset" name="r" value="reset">
+			</form>`,
+			want: "POST http://example.com/login\ncsrf=t0k&remember=on&plan=pro&user=bob",
+		},
+		{
+			name: "first submit button clicked",
+			html: `<form method="post"><input name="a" value="1">
+				<button type="button" name="b0" value="x">B0</button>
+				<button name="b1" value="one">One</button>
+				<input type="submit" name="b2" value="two"></form>`,
+			want: "POST http://example.com/dir/page.html\na=1&b1=one",
+		},
+		{
+			name: "click by name",
+			html: `<form method="post"><button name="b1" value="one">One</button><input type="submit" name="b2" value="two"></form>`,
+			ov:   FormOverrides{Click: "b2"},
+			want: "POST http://example.com/dir/page.html\nb2=two",
+		},
+		{
+			name: "click by button text",
+			html: `<form method="post"><button name="b1" value="one">One</button><button name="b2" value="two"> Second
+				button </button></form>`,
+			ov:   FormOverrides{Click: "Second button"},
+			want: "POST http://example.com/dir/page.html\nb2=two",
+		},
+		{
+			name: "no click",
+			html: `<form method="post"><input name="a" value="1"><button name="b1" value="one">One</button></form>`,
+			ov:   FormOverrides{NoClick: true},
+			want: "POST http://example.com/dir/page.html\na=1",
+		},
+		{
+			name: "image submitter",
+			html: `<form><input type="image" name="map" src="m.png"></form>`,
+			want: "GET http://example.com/dir/page.html?map.x=0&map.y=0",
+		},
+		{
+			name: "submitter formaction and formmethod",
+			html: `<form action="/a" method="get"><input name="q" value="1">
+				<button name="go" value="1" formaction="/b" formmethod="post">Go</button></form>`,
+			want: "POST http://example.com/b\nq=1&go=1",
+		},
+		{
+			name: "disabled controls",
+			html: `<form><input name="a" value="1" disabled>
+				<button name="skip" disabled>Skip</button>
+				<fieldset disabled>
+					<legend><input name="in_legend" value="2"></legend>
+					<input name="in_fieldset" value="3">
+					<legend><input name="second_legend" value="4"></legend>
+				</fieldset>
+				<fieldset><input name="enabled" value="5"></fieldset>
+				<button name="ok" value="6">OK</button></form>`,
+			want: "GET http://example.com/dir/page.html?in_legend=2&enabled=5&ok=6",
+		},
+		{
+			name: "form attribute",
+			html: `<input name="before" value="0" form="f1">
+				<form id="f1"><input name="inside" value="1"><input name="elsewhere" value="x" form="f2"></form>
+				<textarea name="after" form="f1">text</textarea>
+				<input name="unrelated" value="y">`,
+			want: "GET http://example.com/dir/page.html?before=0&inside=1&after=text",
+		},
+		{
+			name: "select defaults",
+			html: `<form>
+				<select name="first"><option disabled value="d">D</option><option value="a">A</option><option value="b">B</option></select>
+				<select name="selected"><option value="a" selected>A</option><option value="b" selected>B</option></select>
+				<select name="multi" multiple><option value="a">A</option></select>
+				<select name="multi2" multiple><option value="a" selected>A</option><option selected>  Option
+					B </option></select>
+				<select name="empty"></select>
+			</form>`,
+			want: "GET http://example.com/dir/page.html?first=a&selected=b&multi2=a&multi2=Option+B",
+		},
+		{
+			name: "textarea keeps text",
+			html: "<form><textarea name=\"t\">\nline1\n  line2</textarea></form>",
+			want: "GET http://example.com/dir/page.html?t=line1%0A++line2",
+		},
+		{
+			name: "overrides",
+			html: `<form method="post"><input name="a" value="1"><input name="b" value="2"><input name="a" value="3"><input name="c" value="4"></form>`,
+			ov:   FormOverrides{Data: map[string]string{"a": "x", "z": "26", "y": "25"}, Remove: []string{"c"}},
+			want: "POST http://example.com/dir/page.html\na=x&b=2&y=25&z=26",
+		},
+		{
+			name: "method and action overrides",
+			html: `<form action="/a"><input name="q" value="1"></form>`,
+			ov:   FormOverrides{Method: "post", Action: "/b"},
+			want: "POST http://example.com/b\nq=1",
+		},
+		{
+			name: "lowercase post",
+			html: `<form method=" Post "><input name="q" value="1"></form>`,
+			want: "POST http://example.com/dir/page.html\nq=1",
+		},
+		{
+			name: "text plain",
+			html: `<form method="post" enctype="text/plain"><input name="a" value="1 2"><input name="b" value="x"></form>`,
+			want: "POST http://example.com/dir/page.html\na=1 2\r\nb=x\r\n",
+		},
+		{
+			name: "file input without override sends empty name",
+			html: `<form method="post"><input type="file" name="f"><input name="a" value="1"></form>`,
+			want: "POST http://example.com/dir/page.html\nf=&a=1",
+		},
+		{
+			name: "accept-charset",
+			html: `<form accept-charset="gbk"><input name="q" value="中文"></form>`,
+			want: "GET http://example.com/dir/page.html?q=%D6%D0%CE%C4",
+		},
+		{
+			name: "unknown accept-charset falls back to page",
+			html: `<form accept-charset="nope"><input name="q" value="中"></form>`,
+			want: "GET http://example.com/dir/page.html?q=%E4%B8%AD",
+		},
+		{
+			name: "select by name",
+			html: `<form name="a"><input name="q" value="1"></form><form id="b"><input name="q" value="2"></form>`,
+			sel:  FormSelector{Name: "b"},
+			want: "GET http://example.com/dir/page.html?q=2",
+		},
+		{
+			name: "select by css inside form",
+			html: `<form><input name="q" value="1"></form><form><input class="x" name="q" value="2"></form>`,
+			sel:  FormSelector{CSS: "input.x"},
+			want: "GET http://example.com/dir/page.html?q=2",
+		},
+		{
+			name: "select by xpath and index",
+			html: `<form><input name="q" value="1"></form><form><input name="q" value="2"></form>`,
+			sel:  FormSelector{XPath: "//form", Index: 1},
+			want: "GET http://example.com/dir/page.html?q=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := FormRequestFromResponse(formResponse(tt.html), tt.sel, tt.ov)
+			if err != nil {
+				t.Fatalf("FormRequestFromResponse: %v", err)
+			}
+			if got := formSummary(req); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			if ref := req.Headers["Referer"]; ref != formPageURL {
+				t.Errorf("Referer = %q, want %q", ref, formPageURL)
+			}
+		})
+	}
+}
+
+func TestFormRequestPageEncoding(t *testing.T) {
+	// 页面为 GBK 时字段同样按 GBK 编码
+	body := append([]byte(`<form><input name="q" value="`), 0xD6, 0xD0)
+	body = append(body, []byte(`"></form>`)...)
+	resp := NewResponse(formPageURL, 200, map[string]string{"Content-Type": "text/html; charset=gbk"}, body, New(formPageURL), "HTTP/1.1")
+	req, err := FormRequestFromResponse(resp, FormSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "GET http://example.com/dir/page.html?q=%D6%D0"; formSummary(req) != want {
+		t.Errorf("got %s, want %s", formSummary(req), want)
+	}
+}
+
+func TestFormRequestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		sel  FormSelector
+		ov   FormOverrides
+	}{
+		{"no form", `<p>nothing</p>`, FormSelector{}, FormOverrides{}},
+		{"index out of range", `<form></form>`, FormSelector{Index: 1}, FormOverrides{}},
+		{"negative index", `<form></form>`, FormSelector{Index: -1}, FormOverrides{}},
+		{"name not found", `<form name="a"></form>`, FormSelector{Name: "b"}, FormOverrides{}},
+		{"bad xpath", `<form></form>`, FormSelector{XPath: "//form["}, FormOverrides{}},
+		{"click not found", `<form><button name="a">A</button></form>`, FormSelector{}, FormOverrides{Click: "b"}},
+		{"click disabled", `<form><button name="a" disabled>A</button></form>`, FormSelector{}, FormOverrides{Click: "a"}},
+		{"override put", `<form><input name="q"></form>`, FormSelector{}, FormOverrides{Method: "PUT"}},
+		{"form method dialog", `<form method="dialog"><input name="q"></form>`, FormSelector{}, FormOverrides{}},
+		{"form method delete", `<form method="delete"><input name="q"></form>`, FormSelector{}, FormOverrides{}},
+		{"formmethod put", `<form><button formmethod="put">Go</button></form>`, FormSelector{}, FormOverrides{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if req, err := FormRequestFromResponse(formResponse(tt.html), tt.sel, tt.ov); err == nil {
+				t.Errorf("succeeded with %s, want error", formSummary(req))
+			}
+		})
+	}
+}
+
+func TestFormRequestMultipart(t *testing.T) {
+	html := `<form method="post" action="/upload">
+		<input type="hidden" name="csrf" value="t0k">
+		<input type="file" name="doc">
+		<input name="title" value="报告">
+		<button name="send" value="1">Send</button>
+	</form>`
+	req, err := FormRequestFromResponse(formResponse(html), FormSelector{}, FormOverrides{
+		Files: map[string]FormFile{
+			"doc":   {Filename: `a"b.txt`, Content: []byte("hello")},
+			"extra": {Filename: "x.png", ContentType: "image/png", Content: []byte{0x89, 'P'}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.URL != "http://example.com/upload" {
+		t.Fatalf("got %s %s", req.Method, req.URL)
+	}
+	mediaType, params, err := mime.ParseMediaType(req.Headers["Content-Type"])
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("Content-Type = %q", req.Headers["Content-Type"])
+	}
+
+	type part struct{ name, filename, contentType, body string }
+	want := []part{
+		{"csrf", "", "", "t0k"},
+		{"doc", `a"b.txt`, "application/octet-stream", "hello"},
+		{"title", "", "", "报告"},
+		{"send", "", "", "1"},
+		{"extra", "x.png", "image/png", "\x89P"},
+	}
+	r := multipart.NewReader(strings.NewReader(string(req.Body)), params["boundary"])
+	for i := 0; ; i++ {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d parts, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Fatalf("unexpected part %q", p.FormName())
+		}
+		body, _ := io.ReadAll(p)
+		got := part{p.FormName(), p.FileName(), "", string(body)}
+		if got.filename != "" {
+			got.contentType = p.Header.Get("Content-Type")
+		}
+		if got != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package httpc
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Get 按 gjson 路径语法查询 JSON 响应体，如 "data.items.#.url"、"data.items.0.id"。
+// 路径不存在时返回的 Result.Exists() 为 false。
+func (r *Response) Get(path string) gjson.Result {
+	return gjson.Get(r.Text(), path)
+}
+
+// GetMany 一次查询多个 gjson 路径
+func (r *Response) GetMany(paths ...string) []gjson.Result {
+	return gjson.GetMany(r.Text(), paths...)
+}
+
+// JSONPath 对 JSON 响应体执行 JSONPath 查询，如 "$.data.items[*].id"，语法见 JSONPath 类型
+func (r *Response) JSONPath(expr string) ([]gjson.Result, error) {
+	jp, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	text := r.Text()
+	if !gjson.Valid(text) {
+		return nil, errors.New("响应体不是有效的 JSON")
+	}
+	return jp.Find(gjson.Parse(text)), nil
+}
+
+// EmbeddedJSON 解析 CSS 选择器匹配到的 <script> 中的 JSON，跳过无法解析的脚本。
+// 除了纯 JSON，也支持 "window.__STATE__ = {...};" 这类赋值语句，取第一个完整的 JSON 值。
+func (r *Response) EmbeddedJSON(selector string) ([]gjson.Result, error) {
+	scripts := r.CSS(selector)
+	if err := scripts.Error(); err != nil {
+		return nil, err
+	}
+	var results []gjson.Result
+	for _, s := range scripts.All() {
+		if v, ok := parseScriptJSON(scriptText(s)); ok {
+			results = append(results, v)
+		}
+	}
+	return results, nil
+}
+
+// NextData 返回 Next.js 页面 <script id="__NEXT_DATA__"> 中的数据，不存在时 Exists() 为 false
+func (r *Response) NextData() gjson.Result {
+	results, err := r.EmbeddedJSON("script#__NEXT_DATA__")
+	if err != nil || len(results) == 0 {
+		return gjson.Result{}
+	}
+	return results[0]
+}
+
+// JSONLD 返回所有 <script type="application/ld+json"> 块，顶层为数组的块会展开为多个结果
+func (r *Response) JSONLD() []gjson.Result {
+	blocks, err := r.EmbeddedJSON(`script[type="application/ld+json"]`)
+	if err != nil {
+		return nil
+	}
+	var results []gjson.Result
+	for _, b := range blocks {
+		if b.IsArray() {
+			results = append(results, b.Array()...)
+		} else {
+			results = append(results, b)
+		}
+	}
+	return results
+}
+
+// ScriptVar 在页面脚本中查找形如 "name = {...}" 或 "name: [...]" 的赋值并解析其 JSON 值，
+// 如 ScriptVar("window.__INITIAL_STATE__")。找不到时 Exists() 为 false
+func (r *Response) ScriptVar(name string) gjson.Result {
+	pattern := MustCompileRegex(regexp.QuoteMeta(name) + `["']?\s*[:=]\s*`)
+	for _, s := range r.CSS("script").All() {
+		text := scriptText(s)
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			if v, ok := parseScriptJSON(text[loc[1]:]); ok {
+				return v
+			}
+		}
+	}
+	return gjson.Result{}
+}
+
+// scriptText 返回脚本内容，去掉 HTML 注释包裹和 CDATA 标记
+func scriptText(s *Selector) string {
+	var b strings.Builder
+	for _, t := range s.CSS("::text").All() {
+		b.WriteString(t.Get())
+	}
+	text := strings.TrimSpace(b.String())
+	for _, marker := range []string{"<!--", "//<![CDATA[", "<![CDATA["} {
+		text = strings.TrimPrefix(text, marker)
+	}
+	for _, marker := range []string{"-->", "//]]>", "]]>"} {
+		text = strings.TrimSuffix(text, marker)
+	}
+	return strings.TrimSpace(text)
+}
+
+// parseScriptJSON 从文本开头（跳过赋值前缀）解析第一个完整的 JSON 对象或数组
+func parseScriptJSON(text string) (gjson.Result, bool) {
+	if gjson.Valid(text) {
+		return gjson.Parse(text), true
+	}
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return gjson.Result{}, false
+	}
+	// json.Decoder 只读取第一个完整的值，忽略其后的 ";" 等脚本内容
+	var raw json.RawMessage
+	if err := json.NewDecoder(strings.NewReader(text[start:])).Decode(&raw); err != nil {
+		return gjson.Result{}, false
+	}
+	return gjson.ParseBytes(raw), true
+}
@@ -0,0 +1,624 @@
+package httpc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// JSONPath 编译后的 JSONPath 表达式，可在多个 goroutine 中复用。支持的语法：
+//
+//	$                 根节点（可省略）
+//	.name  ['name']   子字段
+//	[0]  [-1]         数组下标，负数从末尾计数
+//	[0,2]  ['a','b']  多个下标或字段
+//	[1:5]  [::2]      数组切片
+//	.*  [*]           所有子元素
+//	..name  ..*       递归查找
+//	[?(@.price < 10)] 过滤，支持 == != < <= > >= =~（/正则/）、&&、||，以及 [?(@.isbn)] 存在判断
+type JSONPath struct {
+	expr  string
+	steps []jpStep
+}
+
+// jpStep 路径中的一步
+type jpStep struct {
+	recursive bool // 以 .. 开头，作用于当前节点及其全部后代
+	sel       jpSelector
+}
+
+// 选择器类型
+const (
+	jpName = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+// jpSelector 一步中的选择器
+type jpSelector struct {
+	kind    int
+	names   []string
+	indexes []int
+	slice   [3]*int // start:end:step
+	filter  *jpFilterExpr
+}
+
+// CompileJSONPath 编译 JSONPath 表达式，结果在全局缓存中共享，JSONPath 可并发使用
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	v, err := jsonPathCache.get(expr, func() (any, error) {
+		p := &jpParser{src: strings.TrimSpace(expr)}
+		steps, err := p.parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %v", expr, err)
+		}
+		return &JSONPath{expr: expr, steps: steps}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*JSONPath), nil
+}
+
+// String 返回原始表达式
+func (jp *JSONPath) String() string {
+	return jp.expr
+}
+
+// Find 在 root 上执行表达式，按文档顺序返回全部匹配结果
+func (jp *JSONPath) Find(root gjson.Result) []gjson.Result {
+	nodes := []gjson.Result{root}
+	for _, step := range jp.steps {
+		var next []gjson.Result
+		for _, n := range nodes {
+			if step.recursive {
+				for _, d := range descendants(n) {
+					next = append(next, step.sel.apply(d)...)
+				}
+			} else {
+				next = append(next, step.sel.apply(n)...)
+			}
+		}
+		nodes = next
+		if len(nodes) == 0 {
+			break
+		}
+	}
+	return nodes
+}
+
+// descendants 返回 n 自身及全部后代
+func descendants(n gjson.Result) []gjson.Result {
+	res := []gjson.Result{n}
+	if n.IsObject() || n.IsArray() {
+		n.ForEach(func(_, v gjson.Result) bool {
+			res = append(res, descendants(v)...)
+			return true
+		})
+	}
+	return res
+}
+
+// apply 对单个节点执行选择器
+func (s *jpSelector) apply(n gjson.Result) []gjson.Result {
+	var res []gjson.Result
+	switch s.kind {
+	case jpName:
+		if !n.IsObject() {
+			return nil
+		}
+		for _, name := range s.names {
+			n.ForEach(func(k, v gjson.Result) bool {
+				if k.String() == name {
+					res = append(res, v)
+					return false
+				}
+				return true
+			})
+		}
+	case jpWildcard:
+		if n.IsObject() || n.IsArray() {
+			n.ForEach(func(_, v gjson.Result) bool {
+				res = append(res, v)
+				return true
+			})
+		}
+	case jpIndex:
+		if !n.IsArray() {
+			return nil
+		}
+		arr := n.Array()
+		for _, i := range s.indexes {
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				res = append(res, arr[i])
+			}
+		}
+	case jpSlice:
+		if !n.IsArray() {
+			return nil
+		}
+		arr := n.Array()
+		start, end, step := sliceBounds(s.slice, len(arr))
+		if step > 0 {
+			for i := start; i < end; i += step {
+				res = append(res, arr[i])
+			}
+		} else {
+			for i := start; i > end; i += step {
+				res = append(res, arr[i])
+			}
+		}
+	case jpFilter:
+		if n.IsObject() || n.IsArray() {
+			n.ForEach(func(_, v gjson.Result) bool {
+				if s.filter.match(v) {
+					res = append(res, v)
+				}
+				return true
+			})
+		}
+	}
+	return res
+}
+
+// sliceBounds 按 Python 切片规则计算下标范围
+func sliceBounds(slice [3]*int, length int) (start, end, step int) {
+	step = 1
+	if slice[2] != nil && *slice[2] != 0 {
+		step = *slice[2]
+	}
+	norm := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += length
+		}
+		lo, hi := 0, length
+		if step < 0 {
+			lo, hi = -1, length-1
+		}
+		return max(lo, min(i, hi))
+	}
+	if step > 0 {
+		return norm(slice[0], 0), norm(slice[1], length), step
+	}
+	return norm(slice[0], length-1), norm(slice[1], -1), step
+}
+
+// jpParser 表达式解析器
+type jpParser struct {
+	src string
+	pos int
+}
+
+func (p *jpParser) parse() ([]jpStep, error) {
+	if strings.HasPrefix(p.src, "$") {
+		p.pos = 1
+	} else if p.src != "" && p.src[0] != '.' && p.src[0] != '[' {
+		// 允许省略 $. 前缀，如 "data.items[0]"
+		p.src = "." + p.src
+	}
+
+	var steps []jpStep
+	for p.pos < len(p.src) {
+		step := jpStep{}
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			step.recursive = true
+			p.pos += 2
+			if p.pos < len(p.src) && p.src[p.pos] == '[' {
+				sel, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				step.sel = sel
+			} else {
+				step.sel = p.dotName()
+			}
+		case p.src[p.pos] == '.':
+			p.pos++
+			step.sel = p.dotName()
+		case p.src[p.pos] == '[':
+			sel, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			step.sel = sel
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos], p.pos)
+		}
+		if step.sel.kind == jpName && len(step.sel.names) == 1 && step.sel.names[0] == "" {
+			return nil, fmt.Errorf("empty name at %d", p.pos)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// dotName 解析 .name 或 .*
+func (p *jpParser) dotName() jpSelector {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != '.' && p.src[p.pos] != '[' && p.src[p.pos] != ']' {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if name == "*" {
+		return jpSelector{kind: jpWildcard}
+	}
+	return jpSelector{kind: jpName, names: []string{name}}
+}
+
+// bracket 解析 [...] 中的内容
+func (p *jpParser) bracket() (jpSelector, error) {
+	end, err := matchBracket(p.src, p.pos)
+	if err != nil {
+		return jpSelector{}, err
+	}
+	inner := strings.TrimSpace(p.src[p.pos+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case inner == "*":
+		return jpSelector{kind: jpWildcard}, nil
+	case strings.HasPrefix(inner, "?"):
+		body := strings.TrimSpace(inner[1:])
+		if !strings.HasPrefix(body, "(") || !strings.HasSuffix(body, ")") {
+			return jpSelector{}, fmt.Errorf("filter must be ?(...)")
+		}
+		f, err := parseFilter(body[1 : len(body)-1])
+		if err != nil {
+			return jpSelector{}, err
+		}
+		return jpSelector{kind: jpFilter, filter: f}, nil
+	}
+
+	parts := splitTopLevel(inner, ',')
+	if len(parts) == 1 && strings.Contains(inner, ":") && !isQuoted(inner) {
+		return parseSlice(inner)
+	}
+
+	sel := jpSelector{}
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if isQuoted(part) {
+			sel.kind = jpName
+			sel.names = append(sel.names, part[1:len(part)-1])
+			continue
+		}
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return jpSelector{}, fmt.Errorf("invalid index %q", part)
+		}
+		sel.kind = jpIndex
+		sel.indexes = append(sel.indexes, i)
+	}
+	if len(sel.names) > 0 && len(sel.indexes) > 0 {
+		return jpSelector{}, fmt.Errorf("cannot mix names and indexes in %q", inner)
+	}
+	return sel, nil
+}
+
+// parseSlice 解析 start:end:step
+func parseSlice(s string) (jpSelector, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) > 3 {
+		return jpSelector{}, fmt.Errorf("invalid slice %q", s)
+	}
+	sel := jpSelector{kind: jpSlice}
+	for i, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return jpSelector{}, fmt.Errorf("invalid slice %q", s)
+		}
+		sel.slice[i] = &n
+	}
+	return sel, nil
+}
+
+// matchBracket 返回与 open 处 '[' 匹配的 ']' 位置，忽略引号和正则中的内容
+func matchBracket(s string, open int) (int, error) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed '[' at %d", open)
+}
+
+// splitTopLevel 按 sep 拆分，忽略引号、括号和正则字面量内的分隔符
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isQuoted 判断是否为单引号或双引号包围的字符串
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
+
+// jpFilterExpr 过滤表达式：or 中任一 and 组全部成立即匹配
+type jpFilterExpr struct {
+	or [][]jpCond
+}
+
+// jpCond 单个比较条件
+type jpCond struct {
+	left  *JSONPath // @ 开头的相对路径
+	op    string    // 为空表示存在判断
+	right jpOperand
+}
+
+// jpOperand 比较的右值：字面量、相对路径或正则
+type jpOperand struct {
+	path  *JSONPath
+	value gjson.Result
+	re    *regexp.Regexp
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+// parseFilter 解析 ?( ) 内的表达式
+func parseFilter(s string) (*jpFilterExpr, error) {
+	f := &jpFilterExpr{}
+	for _, orPart := range splitLogical(s, "||") {
+		var group []jpCond
+		for _, andPart := range splitLogical(orPart, "&&") {
+			cond, err := parseCond(strings.TrimSpace(andPart))
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, cond)
+		}
+		f.or = append(f.or, group)
+	}
+	return f, nil
+}
+
+// splitLogical 按 && 或 || 拆分，忽略引号内的内容
+func splitLogical(s, op string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || (c == '/' && i > 0 && strings.HasSuffix(strings.TrimSpace(s[:i]), "=~")):
+			quote = c
+		case strings.HasPrefix(s[i:], op):
+			parts = append(parts, s[start:i])
+			i += len(op) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseCond 解析单个条件，如 @.price < 10
+func parseCond(s string) (jpCond, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "("), ")"))
+	opIdx, op := -1, ""
+	var quote byte
+	for i := 0; i < len(s) && opIdx < 0; i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		for _, candidate := range filterOps {
+			if strings.HasPrefix(s[i:], candidate) {
+				opIdx, op = i, candidate
+				break
+			}
+		}
+	}
+
+	leftSrc := s
+	if opIdx >= 0 {
+		leftSrc = strings.TrimSpace(s[:opIdx])
+	}
+	left, err := relativePath(leftSrc)
+	if err != nil {
+		return jpCond{}, err
+	}
+	cond := jpCond{left: left, op: op}
+	if opIdx < 0 {
+		return cond, nil
+	}
+
+	rightSrc := strings.TrimSpace(s[opIdx+len(op):])
+	switch {
+	case op == "=~":
+		if len(rightSrc) < 2 || rightSrc[0] != '/' {
+			return jpCond{}, fmt.Errorf("=~ requires /regex/")
+		}
+		last := strings.LastIndexByte(rightSrc, '/')
+		if last <= 0 {
+			return jpCond{}, fmt.Errorf("=~ requires /regex/")
+		}
+		pattern, flags := rightSrc[1:last], rightSrc[last+1:]
+		if strings.Contains(flags, "i") {
+			pattern = "(?i)" + pattern
+		}
+		re, err := CompileRegex(pattern)
+		if err != nil {
+			return jpCond{}, err
+		}
+		cond.right.re = re
+	case strings.HasPrefix(rightSrc, "@"):
+		rp, err := relativePath(rightSrc)
+		if err != nil {
+			return jpCond{}, err
+		}
+		cond.right.path = rp
+	case isQuoted(rightSrc):
+		cond.right.value = gjson.Result{Type: gjson.String, Str: rightSrc[1 : len(rightSrc)-1]}
+	default:
+		v := gjson.Parse(rightSrc)
+		if !v.Exists() || v.Raw != rightSrc {
+			return jpCond{}, fmt.Errorf("invalid literal %q", rightSrc)
+		}
+		cond.right.value = v
+	}
+	return cond, nil
+}
+
+// relativePath 编译 @ 开头的相对路径
+func relativePath(s string) (*JSONPath, error) {
+	if !strings.HasPrefix(s, "@") {
+		return nil, fmt.Errorf("filter operand must start with @: %q", s)
+	}
+	p := &jpParser{src: "$" + s[1:], pos: 1}
+	steps, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &JSONPath{expr: s, steps: steps}, nil
+}
+
+// match 判断节点是否满足过滤条件
+func (f *jpFilterExpr) match(n gjson.Result) bool {
+	for _, group := range f.or {
+		ok := true
+		for _, cond := range group {
+			if !cond.match(n) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *jpCond) match(n gjson.Result) bool {
+	found := c.left.Find(n)
+	if c.op == "" {
+		return len(found) > 0
+	}
+	if len(found) == 0 {
+		return c.op == "!="
+	}
+	left := found[0]
+
+	if c.right.re != nil {
+		return left.Type == gjson.String && c.right.re.MatchString(left.Str)
+	}
+	right := c.right.value
+	if c.right.path != nil {
+		rf := c.right.path.Find(n)
+		if len(rf) == 0 {
+			return c.op == "!="
+		}
+		right = rf[0]
+	}
+
+	cmp, comparable := compareJSON(left, right)
+	switch c.op {
+	case "==":
+		return comparable && cmp == 0
+	case "!=":
+		return !comparable || cmp != 0
+	case "<":
+		return comparable && cmp < 0
+	case "<=":
+		return comparable && cmp <= 0
+	case ">":
+		return comparable && cmp > 0
+	case ">=":
+		return comparable && cmp >= 0
+	}
+	return false
+}
+
+// compareJSON 比较两个同类型的值，类型不同时 ok 为 false
+func compareJSON(a, b gjson.Result) (cmp int, ok bool) {
+	switch {
+	case a.Type == gjson.Number && b.Type == gjson.Number:
+		switch {
+		case a.Num < b.Num:
+			return -1, true
+		case a.Num > b.Num:
+			return 1, true
+		}
+		return 0, true
+	case a.Type == gjson.String && b.Type == gjson.String:
+		return strings.Compare(a.Str, b.Str), true
+	case (a.Type == gjson.True || a.Type == gjson.False) && (b.Type == gjson.True || b.Type == gjson.False):
+		if a.Type == b.Type {
+			return 0, true
+		}
+		return 1, true
+	case a.Type == gjson.Null && b.Type == gjson.Null:
+		return 0, true
+	case a.Type == gjson.JSON && b.Type == gjson.JSON:
+		return strings.Compare(a.Raw, b.Raw), true
+	}
+	return 0, false
+}
@@ -0,0 +1,142 @@
+package httpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const jsonPathDoc = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "LOTR", "isbn": "0-395", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 19.95}
+	},
+	"nums": [0, 1, 2, 3, 4, 5],
+	"flags": [{"on": true, "n": null}, {"on": false}],
+	"a.b": "dotted"
+}`
+
+// raws 将结果的原始 JSON 以 | 连接，便于比较
+func raws(results []gjson.Result) string {
+	parts := make([]string, len(results))
+	for i, r := range results {
+		parts[i] = r.Raw
+	}
+	return strings.Join(parts, "|")
+}
+
+func TestJSONPathFind(t *testing.T) {
+	root := gjson.Parse(jsonPathDoc)
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"root", "$", strings.TrimSpace(jsonPathDoc)},
+		{"child", "$.store.bicycle.color", `"red"`},
+		{"without dollar", "store.bicycle.color", `"red"`},
+		{"quoted name", "$['a.b']", `"dotted"`},
+		{"quoted name with bracket", "$['x]']", ""},
+		{"multiple names", "$.store.bicycle['color','price']", `"red"|19.95`},
+		{"missing", "$.store.car", ""},
+		{"index", "$.nums[2]", "2"},
+		{"negative index", "$.nums[-1]", "5"},
+		{"index out of range", "$.nums[10]", ""},
+		{"multiple indexes", "$.nums[0,3]", "0|3"},
+		{"wildcard array", "$.store.book[*].price", "8.95|12.99|8.99|22.99"},
+		{"wildcard object", "$.store.bicycle.*", `"red"|19.95`},
+
+		{"slice", "$.nums[1:4]", "1|2|3"},
+		{"slice open end", "$.nums[4:]", "4|5"},
+		{"slice open start", "$.nums[:2]", "0|1"},
+		{"slice step", "$.nums[::2]", "0|2|4"},
+		{"slice negative start", "$.nums[-2:]", "4|5"},
+		{"slice reverse", "$.nums[::-2]", "5|3|1"},
+		{"slice clamped", "$.nums[3:100]", "3|4|5"},
+		{"slice empty", "$.nums[4:2]", ""},
+		{"slice on object", "$.store[0:1]", ""},
+
+		{"recursive name", "$..author", `"Nigel Rees"|"Evelyn Waugh"|"Herman Melville"|"J. R. R. Tolkien"`},
+		{"recursive price", "$.store..price", "8.95|12.99|8.99|22.99|19.95"},
+		{"recursive index", "$..book[0].title", `"Sayings"`},
+		{"recursive bracket", "$..['color']", `"red"`},
+		{"recursive wildcard count", "$.store.bicycle..*", `"red"|19.95`},
+
+		{"filter less", "$.store.book[?(@.price < 9)].title", `"Sayings"|"Moby Dick"`},
+		{"filter string equal", "$.store.book[?(@.category == 'reference')].title", `"Sayings"`},
+		{"filter double quotes", `$.store.book[?(@.category == "fiction")].price`, "12.99|8.99|22.99"},
+		{"filter not equal", "$.store.book[?(@.category != 'fiction')].title", `"Sayings"`},
+		{"filter exists", "$.store.book[?(@.isbn)].title", `"Moby Dick"|"LOTR"`},
+		{"filter and", "$.store.book[?(@.category == 'fiction' && @.price < 20)].title", `"Sword"|"Moby Dick"`},
+		{"filter or", "$.store.book[?(@.price < 9 || @.price > 20)].title", `"Sayings"|"Moby Dick"|"LOTR"`},
+		{"filter regex", "$.store.book[?(@.author =~ /^J\\./)].title", `"LOTR"`},
+		{"filter regex flags", "$.store.book[?(@.title =~ /moby/i)].price", "8.99"},
+		{"filter path operand", "$.store.book[?(@.price > @.missing)].title", ""},
+		{"filter bool", "$.flags[?(@.on == true)]", `{"on": true, "n": null}`},
+		{"filter null", "$.flags[?(@.n == null)].on", "true"},
+		{"filter missing not equal", "$.flags[?(@.n != null)].on", "false"},
+		{"filter on array values", "$.nums[?(@ >= 4)]", "4|5"},
+		{"recursive filter", "$..[?(@.price > 19)].price", "19.95|22.99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jp, err := CompileJSONPath(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileJSONPath(%q): %v", tt.expr, err)
+			}
+			if got := raws(jp.Find(root)); got != tt.want {
+				t.Errorf("Find(%q) = %s, want %s", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPathMalformed(t *testing.T) {
+	tests := []string{
+		"$.",
+		"$..",
+		"$.a[",
+		"$.a[1",
+		"$.a]",
+		"$.a[x]",
+		"$.a[1,'b']",
+		"$.a[1:2:3:4]",
+		"$.a[1:x]",
+		"$.a[?@.b]",
+		"$.a[?(b == 1)]",
+		"$.a[?(@.b == )]",
+		"$.a[?(@.b == bare)]",
+		"$.a[?(@.b =~ x)]",
+		"$.a[?(@.b =~ /x)]",
+		"$.a[?(@.b =~ /)]",
+		"$.a[?(@.b =~ /(/)]",
+		"$.a['unterminated]",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := CompileJSONPath(expr); err == nil {
+				t.Errorf("CompileJSONPath(%q) succeeded, want error", expr)
+			}
+		})
+	}
+}
+
+func TestJSONPathCached(t *testing.T) {
+	a, err := CompileJSONPath("$.store.book[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := MustCompileJSONPath("$.store.book[0]")
+	if a != b {
+		t.Errorf("CompileJSONPath returned different instances for the same expression")
+	}
+	if a.String() != "$.store.book[0]" {
+		t.Errorf("String() = %q", a.String())
+	}
+}
@@ -0,0 +1,294 @@
+package httpc
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// 伪元素类型
+const (
+	pseudoNone = iota
+	pseudoText // ::text
+	pseudoAttr // ::attr(name)
+)
+
+// cssPart 逗号分隔的一段 CSS 选择器及其伪元素
+type cssPart struct {
+	sel        cascadia.Sel // 为 nil 表示当前节点本身
+	pseudo     int
+	attr       string // ::attr 的属性名
+	descendant bool   // 伪元素前有空格，作用于所有后代（如 "div ::text"）
+}
+
+// cssQuery 编译后的 CSS 查询
+type cssQuery struct {
+	group cascadia.SelectorGroup // 不含伪元素时整体编译，保持文档顺序
+	parts []cssPart
+}
+
+var pseudoPattern = regexp.MustCompile(`^(.*?)(\s*)::(text|attr\(\s*([^)\s]+)\s*\))$`)
+
+// compileCSSQuery 编译可能带伪元素的 CSS 选择器
+func compileCSSQuery(selector string) (*cssQuery, error) {
+	if !strings.Contains(selector, "::") {
+		group, err := cascadia.ParseGroup(selector)
+		if err != nil {
+			return nil, err
+		}
+		return &cssQuery{group: group}, nil
+	}
+
+	q := &cssQuery{}
+	for _, raw := range splitSelectorGroup(selector) {
+		raw = strings.TrimSpace(raw)
+		part := cssPart{}
+		base := raw
+		if m := pseudoPattern.FindStringSubmatch(raw); m != nil {
+			base = strings.TrimSpace(m[1])
+			part.descendant = m[2] != ""
+			if m[3] == "text" {
+				part.pseudo = pseudoText
+			} else {
+				part.pseudo = pseudoAttr
+				part.attr = m[4]
+			}
+		}
+		if base == "" {
+			if part.pseudo == pseudoNone {
+				return nil, errors.New("empty selector")
+			}
+		} else {
+			sel, err := cascadia.Parse(base)
+			if err != nil {
+				return nil, err
+			}
+			part.sel = sel
+		}
+		q.parts = append(q.parts, part)
+	}
+	return q, nil
+}
+
+// selectFrom 在 node 下执行查询
+func (q *cssQuery) selectFrom(node *html.Node) []*Selector {
+	var results []*Selector
+	if q.group != nil {
+		for _, n := range cascadia.QueryAll(node, q.group) {
+			results = append(results, &Selector{node: n})
+		}
+		return results
+	}
+
+	for _, part := range q.parts {
+		var matched []*html.Node
+		if part.sel == nil {
+			matched = []*html.Node{node}
+		} else {
+			matched = cascadia.QueryAll(node, part.sel)
+		}
+
+		for _, n := range matched {
+			switch part.pseudo {
+			case pseudoNone:
+				results = append(results, &Selector{node: n})
+			case pseudoText:
+				results = append(results, textNodes(n, part.descendant)...)
+			case pseudoAttr:
+				if part.descendant {
+					for _, d := range descendantElements(n) {
+						if val, ok := attrValue(d, part.attr); ok {
+							results = append(results, newValueSelector(val))
+						}
+					}
+				} else if val, ok := attrValue(n, part.attr); ok {
+					results = append(results, newValueSelector(val))
+				}
+			}
+		}
+	}
+	return results
+}
+
+// textNodes 返回 n 的子文本节点，deep 为 true 时包含所有后代文本节点
+func textNodes(n *html.Node, deep bool) []*Selector {
+	var results []*Selector
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			results = append(results, &Selector{node: c})
+		case deep && c.Type == html.ElementNode:
+			results = append(results, textNodes(c, true)...)
+		}
+	}
+	return results
+}
+
+// descendantElements 返回 n 自身及所有后代元素
+func descendantElements(n *html.Node) []*html.Node {
+	var results []*html.Node
+	var walk func(*html.Node)
+	walk = func(cur *html.Node) {
+		if cur.Type == html.ElementNode {
+			results = append(results, cur)
+		}
+		for c := cur.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return results
+}
+
+// attrValue 读取属性值
+func attrValue(n *html.Node, name string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// splitSelectorGroup 按顶层逗号拆分选择器，忽略括号、方括号和引号内的逗号
+func splitSelectorGroup(selector string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range selector {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, selector[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// evalXPath 在 node 上执行 XPath 表达式。
+// 节点集结果中，属性节点转换为字符串值选择器，文本节点保留为节点；
+// string()、count() 等非节点集结果转换为单个字符串值选择器。
+func evalXPath(node *html.Node, expr string) ([]*Selector, error) {
+	prog, err := compileXPath(expr, nil)
+	if err != nil {
+		return nil, err
+	}
+	exp := prog.acquire()
+	defer prog.release(exp)
+
+	v := exp.Evaluate(htmlquery.CreateXPathNavigator(node))
+	if iter, ok := v.(*xpath.NodeIterator); ok {
+		var results []*Selector
+		for iter.MoveNext() {
+			nav, ok := iter.Current().(*htmlquery.NodeNavigator)
+			if !ok {
+				continue
+			}
+			if nav.NodeType() == xpath.AttributeNode {
+				results = append(results, newValueSelector(nav.Value()))
+			} else {
+				results = append(results, &Selector{node: nav.Current()})
+			}
+		}
+		return results, nil
+	}
+	return xpathScalar(v), nil
+}
+
+// evalXMLXPath 在 XML 节点上执行 XPath 表达式，带前缀的名称按 ns 中的命名空间 URI 匹配，
+// ns 为空时按字面前缀匹配。结果转换规则同 evalXPath
+func evalXMLXPath(node *xmlquery.Node, expr string, ns *xmlNamespaces) ([]*Selector, error) {
+	prog, err := compileXPath(expr, ns.snapshot())
+	if err != nil {
+		return nil, err
+	}
+	exp := prog.acquire()
+	defer prog.release(exp)
+
+	v := exp.Evaluate(xmlquery.CreateXPathNavigator(node))
+	if iter, ok := v.(*xpath.NodeIterator); ok {
+		var results []*Selector
+		for iter.MoveNext() {
+			nav, ok := iter.Current().(*xmlquery.NodeNavigator)
+			if !ok {
+				continue
+			}
+			if nav.NodeType() == xpath.AttributeNode {
+				results = append(results, newValueSelector(nav.Value()))
+			} else {
+				results = append(results, newXMLSelector(nav.Current(), ns))
+			}
+		}
+		return results, nil
+	}
+	return xpathScalar(v), nil
+}
+
+// xpathScalar 将 string()、count() 等非节点集结果转换为字符串值选择器
+func xpathScalar(v any) []*Selector {
+	switch v := v.(type) {
+	case string:
+		return []*Selector{newValueSelector(v)}
+	case float64:
+		return []*Selector{newValueSelector(strconv.FormatFloat(v, 'f', -1, 64))}
+	case bool:
+		return []*Selector{newValueSelector(strconv.FormatBool(v))}
+	}
+	return nil
+}
+
+// xmlNamespaces XML 模式下 XPath 使用的前缀到命名空间 URI 的映射，
+// 同一个文档的所有选择器共享一份，可并发读写
+type xmlNamespaces struct {
+	mu   sync.RWMutex
+	uris map[string]string
+}
+
+// set 注册前缀，override 为 false 时不覆盖已有的前缀
+func (n *xmlNamespaces) set(prefix, uri string, override bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.uris == nil {
+		n.uris = make(map[string]string)
+	}
+	if _, ok := n.uris[prefix]; ok && !override {
+		return
+	}
+	n.uris[prefix] = uri
+}
+
+// snapshot 返回映射的副本，没有注册任何前缀时返回 nil
+func (n *xmlNamespaces) snapshot() map[string]string {
+	if n == nil {
+		return nil
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if len(n.uris) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(n.uris))
+	for k, v := range n.uris {
+		m[k] = v
+	}
+	return m
+}
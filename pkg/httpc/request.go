@@ -8,6 +8,36 @@ type ParseResult struct {
 }
 type ParseFunc func(*Response) *ParseResult
 
+// Emitter 流式回调的输出，请求与 Item 在产生时立即交给引擎入队，不需要等回调返回
+type Emitter interface {
+	Request(req *Request)
+	Item(it *item.StrictItem)
+}
+
+// StreamFunc 流式回调，通过 Emitter 逐个产生请求与 Item，适合一次解析大量结果的页面。
+// Emitter 只在回调返回前有效，回调返回后产生的结果会被丢弃
+type StreamFunc func(*Response, Emitter)
+
+// Collect 将流式回调转换为 ParseFunc，产生的结果先收集到 ParseResult 中，
+// 用于只接受 ParseFunc 的地方（如 CrawlSpider 的规则）
+func Collect(fn StreamFunc) ParseFunc {
+	return func(resp *Response) *ParseResult {
+		result := &ParseResult{}
+		fn(resp, result)
+		return result
+	}
+}
+
+// Request 将请求追加到结果中，ParseResult 本身也是一个 Emitter
+func (p *ParseResult) Request(req *Request) {
+	p.Requests = append(p.Requests, req)
+}
+
+// Item 将 Item 追加到结果中
+func (p *ParseResult) Item(it *item.StrictItem) {
+	p.Items = append(p.Items, it)
+}
+
 type Request struct {
 	URL      string
 	Method   string
@@ -15,6 +45,8 @@ type Request struct {
 	Body     []byte
 	Meta     map[string]any
 	Callback ParseFunc
+	Stream   StreamFunc // 流式回调，设置后优先于 Callback
+	Encoding string     // 强制使用的响应编码（如 gbk），为空时自动检测
 }
 
 func New(url string) *Request {
@@ -49,3 +81,15 @@ func (r *Request) WithCallback(cb ParseFunc) *Request {
 	r.Callback = cb
 	return r
 }
+
+// WithStream 设置流式回调
+func (r *Request) WithStream(fn StreamFunc) *Request {
+	r.Stream = fn
+	return r
+}
+
+// WithEncoding 指定响应的字符编码，覆盖自动检测结果
+func (r *Request) WithEncoding(encoding string) *Request {
+	r.Encoding = encoding
+	return r
+}
@@ -445,21 +445,23 @@
 package httpc
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
+	"encoding/xml"
 	"io"
 	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
 
-	"github.com/andybalholm/cascadia"
 	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
 )
 
-// Response 封装了 HTTP 响应的所有信息，并提供链式调用的 HTML 解析功能。
-// 注意：本类型的方法不是并发安全的，建议在单个 goroutine 中使用。
+// Response 封装了 HTTP 响应的所有信息。
+// HTML 查询通过 CSS / XPath 返回不可变的 SelectorList，不会修改 Response 本身，
+// 因此同一个 Response 可以在多个 goroutine 中并发查询。
 type Response struct {
 	URL        string
 	StatusCode int
@@ -468,9 +470,16 @@ type Response struct {
 	Request    Request
 	Protocol   string
 
-	rootNode *html.Node   // 解析后的 HTML 根节点
-	curNodes []*html.Node // 当前选中的节点集合
-	err      error        // 链式调用中遇到的第一个错误
+	decodeOnce sync.Once
+	text       string // 解码为 UTF-8 的响应体
+	encoding   string // 响应体的字符编码
+	parseOnce  sync.Once
+	rootNode   *html.Node // 解析后的 HTML 根节点
+	err        error      // HTML 解析错误
+	xmlOnce    sync.Once
+	xmlRoot    *xmlquery.Node // 解析后的 XML 根节点
+	xmlErr     error          // XML 解析错误
+	namespaces xmlNamespaces  // XML 模式下 XPath 使用的命名空间
 }
 
 // NewResponse 创建一个新的 Response 实例。
@@ -492,205 +501,159 @@ func NewResponse(
 	}
 }
 
-// check 如果已有错误则直接返回 true，供内部方法快速退出。
-func (r *Response) check() bool {
-	return r.err != nil
-}
-
-// ensureNodes 确保 curNodes 不为空：如果 curNodes 为空但 rootNode 存在，则将其作为当前节点。
-// 返回当前 curNodes 是否非空（且无错误）。
-func (r *Response) ensureNodes() bool {
-	if r.check() {
-		return false
-	}
-	if len(r.curNodes) == 0 && r.rootNode != nil {
-		r.curNodes = []*html.Node{r.rootNode}
+// Header 返回响应头的值，名称不区分大小写。
+func (r *Response) Header(name string) string {
+	if v, ok := r.Headers[name]; ok {
+		return v
 	}
-	return len(r.curNodes) > 0
-}
-
-// ParseHTML 解析 HTML，为后续查询做准备。如果已经解析过，不会重复解析。
-func (r *Response) ParseHTML() *Response {
-	if r.rootNode == nil && r.err == nil {
-		root, err := htmlquery.Parse(bytes.NewReader(r.Body))
-		if err != nil {
-			r.err = err
-			return r
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, name) {
+			return v
 		}
-		r.rootNode = root
-		r.curNodes = []*html.Node{root}
 	}
-	return r
+	return ""
 }
 
-// XPath 使用 XPath 表达式查询，将当前节点集合替换为匹配到的所有节点。
-func (r *Response) XPath(expr string) *Response {
-	if r.check() {
-		return r
-	}
-	r.ParseHTML() // 确保已解析
-	if !r.ensureNodes() {
-		return r
-	}
+// decode 确定字符编码并将响应体解码为 UTF-8，只执行一次。
+// 优先使用请求上指定的编码，否则依次参考 BOM、Content-Type 和 <meta> 声明。
+func (r *Response) decode() {
+	r.decodeOnce.Do(func() {
+		enc, name := charset.Lookup(r.Request.Encoding)
+		if enc == nil {
+			var certain bool
+			enc, name, certain = charset.DetermineEncoding(r.Body, r.Header("Content-Type"))
+			// XML 文档在 <?xml encoding="..."?> 中声明编码，DetermineEncoding 不会识别
+			if !certain {
+				if e, n := charset.Lookup(xmlDeclEncoding(r.Body)); e != nil {
+					enc, name, certain = e, n, true
+				}
+			}
+			// DetermineEncoding 只检查前 1024 字节，没有任何声明时会退回 windows-1252，
+			// 而大部分页面的非 ASCII 字符出现在更靠后的位置，因此对整个响应体再检查一次 UTF-8。
+			if !certain && name == "windows-1252" && utf8.Valid(r.Body) {
+				enc, name = charset.Lookup("utf-8")
+			}
+		}
+		r.encoding = name
 
-	var results []*html.Node
-	for _, node := range r.curNodes {
-		found := htmlquery.Find(node, expr)
-		results = append(results, found...)
-	}
-	r.curNodes = results
-	return r
+		text := string(r.Body)
+		if name != "utf-8" {
+			if decoded, err := enc.NewDecoder().Bytes(r.Body); err == nil {
+				text = string(decoded)
+			}
+		}
+		r.text = strings.TrimPrefix(text, "\uFEFF") // 去掉 BOM
+	})
 }
 
-// CSS 使用 CSS 选择器查询，将当前节点集合替换为匹配到的所有节点。
-func (r *Response) CSS(selector string) *Response {
-	if r.check() {
-		return r
-	}
-	r.ParseHTML()
-	if !r.ensureNodes() {
-		return r
-	}
-
-	sel, err := cascadia.Compile(selector)
-	if err != nil {
-		r.err = fmt.Errorf("invalid CSS selector: %v", err)
-		return r
-	}
-
-	var results []*html.Node
-	for _, node := range r.curNodes {
-		found := cascadia.QueryAll(node, sel)
-		results = append(results, found...)
-	}
-	r.curNodes = results
-	return r
+// Text 返回解码为 UTF-8 的响应体，结果会被缓存。
+func (r *Response) Text() string {
+	r.decode()
+	return r.text
 }
 
-// Find 是 CSS 的别名，提供类似 jQuery 的语义。
-func (r *Response) Find(selector string) *Response {
-	return r.CSS(selector)
+// Encoding 返回响应体的字符编码名称（WHATWG 规范名称，如 utf-8、gbk、shift_jis）。
+func (r *Response) Encoding() string {
+	r.decode()
+	return r.encoding
 }
 
-// First 将当前节点集合缩减为第一个元素。
-func (r *Response) First() *Response {
-	if r.check() || len(r.curNodes) == 0 {
-		return r
-	}
-	r.curNodes = []*html.Node{r.curNodes[0]}
-	return r
+// ParseHTML 解析 HTML，为后续查询做准备。只会解析一次，可并发调用。
+// 解析基于 Text() 的解码结果，因此仅在 <meta> 中声明编码的页面也能得到正确的文本。
+func (r *Response) ParseHTML() error {
+	r.parseOnce.Do(func() {
+		root, err := htmlquery.Parse(strings.NewReader(r.Text()))
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.rootNode = root
+	})
+	return r.err
 }
 
-// Last 将当前节点集合缩减为最后一个元素。
-func (r *Response) Last() *Response {
-	if r.check() || len(r.curNodes) == 0 {
-		return r
-	}
-	r.curNodes = []*html.Node{r.curNodes[len(r.curNodes)-1]}
-	return r
+// IsXML 根据 Content-Type 判断响应是否为 XML 文档（text/xml、application/xml 及 +xml 类型）。
+// application/xhtml+xml 仍按 HTML 处理。
+func (r *Response) IsXML() bool {
+	mediaType, _, _ := strings.Cut(strings.ToLower(r.Header("Content-Type")), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	switch {
+	case mediaType == "application/xhtml+xml":
+		return false
+	case mediaType == "text/xml", mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// ParseXML 按 XML 解析响应体，保留标签大小写和命名空间。只会解析一次，可并发调用。
+// 文档中声明的命名空间前缀会自动注册，供 XPath 使用。
+func (r *Response) ParseXML() error {
+	r.xmlOnce.Do(func() {
+		root, err := xmlquery.ParseWithOptions(strings.NewReader(r.Text()), xmlquery.ParserOptions{
+			Decoder: &xmlquery.DecoderOptions{
+				Strict: false,
+				Entity: xml.HTMLEntity,
+				// Text() 已经解码为 UTF-8，忽略文档声明的编码
+				CharsetReader: func(_ string, input io.Reader) (io.Reader, error) {
+					return input, nil
+				},
+			},
+		})
+		if err != nil {
+			r.xmlErr = err
+			return
+		}
+		r.xmlRoot = root
+		registerDeclaredNamespaces(root, &r.namespaces)
+	})
+	return r.xmlErr
 }
 
-// Eq 将当前节点集合缩减为索引 i 处的元素（从 0 开始）。如果索引越界，集合变为空。
-func (r *Response) Eq(i int) *Response {
-	if r.check() || len(r.curNodes) == 0 {
-		return r
-	}
-	if i < 0 || i >= len(r.curNodes) {
-		r.curNodes = []*html.Node{}
-		return r
-	}
-	r.curNodes = []*html.Node{r.curNodes[i]}
-	return r
+// RegisterNamespace 注册 XML 模式下 XPath 使用的命名空间前缀，覆盖文档中的同名声明。
+// 注册对之后的所有查询生效，应在查询前调用。
+func (r *Response) RegisterNamespace(prefix, uri string) {
+	r.namespaces.set(prefix, uri, true)
 }
 
-// Filter 用自定义函数过滤当前节点集合，保留使 fn 返回 true 的节点。
-func (r *Response) Filter(fn func(*html.Node) bool) *Response {
-	if r.check() {
-		return r
-	}
-	var filtered []*html.Node
-	for _, node := range r.curNodes {
-		if fn(node) {
-			filtered = append(filtered, node)
-		}
+// XMLSelector 按 XML 模式解析并返回根节点选择器，不考虑 Content-Type。
+// 用于 Content-Type 不准确的 XML 文档（如以 text/html 返回的 RSS）。
+func (r *Response) XMLSelector() *Selector {
+	if err := r.ParseXML(); err != nil {
+		return &Selector{err: err}
 	}
-	r.curNodes = filtered
-	return r
+	return newXMLSelector(r.xmlRoot, &r.namespaces)
 }
 
-// Attr 返回当前节点集合中第一个节点的指定属性值。如果节点不存在或属性缺失，返回空字符串。
-func (r *Response) Attr(name string) string {
-	if r.check() || len(r.curNodes) == 0 {
-		return ""
+// Selector 返回以文档根节点为起点的选择器，XML 响应（见 IsXML）使用 XML 模式。
+func (r *Response) Selector() *Selector {
+	if r.IsXML() {
+		return r.XMLSelector()
 	}
-	for _, attr := range r.curNodes[0].Attr {
-		if attr.Key == name {
-			return attr.Val
-		}
+	if err := r.ParseHTML(); err != nil {
+		return &Selector{err: err}
 	}
-	return ""
+	return &Selector{node: r.rootNode}
 }
 
-// Text 返回当前节点集合中所有节点的文本内容拼接（多个节点用换行分隔），并去除首尾空白。
-func (r *Response) Text() (string, error) {
-	if r.check() {
-		return "", r.err
-	}
-	if len(r.curNodes) == 0 {
-		return "", nil
-	}
-	if len(r.curNodes) == 1 {
-		return strings.TrimSpace(htmlquery.InnerText(r.curNodes[0])), nil
-	}
-	var texts []string
-	for _, node := range r.curNodes {
-		texts = append(texts, strings.TrimSpace(htmlquery.InnerText(node)))
-	}
-	return strings.Join(texts, "\n"), nil
+// XPath 在整个文档上执行 XPath 查询。
+func (r *Response) XPath(expr string) SelectorList {
+	return r.Selector().XPath(expr)
 }
 
-// MustText 返回 Text 的结果，忽略错误。如果出错返回空字符串。
-func (r *Response) MustText() string {
-	s, _ := r.Text()
-	return s
+// CSS 在整个文档上执行 CSS 选择器查询。
+func (r *Response) CSS(selector string) SelectorList {
+	return r.Selector().CSS(selector)
 }
 
-// HTML 返回当前节点集合中所有节点的 HTML 源码拼接（多个节点用换行分隔）。
-func (r *Response) HTML() (string, error) {
-	if r.check() {
-		return "", r.err
-	}
-	if len(r.curNodes) == 0 {
-		return "", nil
-	}
-	var buf bytes.Buffer
-	if len(r.curNodes) == 1 {
-		if err := html.Render(&buf, r.curNodes[0]); err != nil {
-			return "", err
-		}
-	} else {
-		for _, node := range r.curNodes {
-			if err := html.Render(&buf, node); err != nil {
-				return "", err
-			}
-			buf.WriteString("\n")
-		}
-	}
-	return buf.String(), nil
-}
-
-// MustHTML 返回 HTML 的结果，忽略错误。
-func (r *Response) MustHTML() string {
-	s, _ := r.HTML()
-	return s
+// Find 是 CSS 的别名，提供类似 jQuery 的语义。
+func (r *Response) Find(selector string) SelectorList {
+	return r.CSS(selector)
 }
 
-// JSON 将响应体解析为 JSON 并存入 v。
+// JSON 将解码后的响应体解析为 JSON 并存入 v。
 func (r *Response) JSON(v interface{}) error {
-	if r.err != nil {
-		return r.err
-	}
-	return json.Unmarshal(r.Body, v)
+	return json.Unmarshal([]byte(r.Text()), v)
 }
 
 // Bytes 返回原始响应体字节切片。
@@ -698,28 +661,14 @@ func (r *Response) Bytes() []byte {
 	return r.Body
 }
 
-// String 返回响应体字符串，自动根据 Content-Type 中的 charset 进行字符集转换。
+// String 返回解码后的响应体字符串，等同于 Text。
 func (r *Response) String() string {
-	if contentType, ok := r.Headers["Content-Type"]; ok {
-		if strings.Contains(strings.ToLower(contentType), "charset=") {
-			reader, err := charset.NewReader(bytes.NewReader(r.Body), contentType)
-			if err == nil {
-				converted, err := io.ReadAll(reader)
-				if err == nil {
-					return string(converted)
-				}
-			}
-		}
-	}
-	return string(r.Body)
+	return r.Text()
 }
 
 // Regex 在响应体字符串上执行正则匹配，返回所有匹配项。
 func (r *Response) Regex(pattern string) ([]string, error) {
-	if r.err != nil {
-		return nil, r.err
-	}
-	re, err := regexp.Compile(pattern)
+	re, err := CompileRegex(pattern)
 	if err != nil {
 		return nil, err
 	}
@@ -732,142 +681,37 @@ func (r *Response) MustRegex(pattern string) []string {
 	return matches
 }
 
-// Children 将当前节点集合替换为每个节点的子元素节点（仅元素节点）。
-func (r *Response) Children() *Response {
-	if r.check() {
-		return r
-	}
-	if !r.ensureNodes() {
-		return r
-	}
-	var children []*html.Node
-	for _, node := range r.curNodes {
-		for child := node.FirstChild; child != nil; child = child.NextSibling {
-			if child.Type == html.ElementNode {
-				children = append(children, child)
-			}
-		}
+// Error 返回 HTML 解析错误（XML 响应为 XML 解析错误），尚未解析或解析成功时返回 nil。
+func (r *Response) Error() error {
+	if r.IsXML() {
+		return r.xmlErr
 	}
-	r.curNodes = children
-	return r
+	return r.err
 }
 
-// Parent 将当前节点集合替换为每个节点的父元素节点（仅元素节点，去重）。
-func (r *Response) Parent() *Response {
-	if r.check() {
-		return r
-	}
-	if !r.ensureNodes() {
-		return r
-	}
-	parentsMap := make(map[*html.Node]bool)
-	var parents []*html.Node
-	for _, node := range r.curNodes {
-		if node.Parent != nil && node.Parent.Type == html.ElementNode {
-			if !parentsMap[node.Parent] {
-				parentsMap[node.Parent] = true
-				parents = append(parents, node.Parent)
-			}
-		}
-	}
-	r.curNodes = parents
-	return r
-}
+var xmlDeclPattern = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
 
-// Next 将当前节点集合替换为每个节点的下一个兄弟元素节点（仅第一个）。
-func (r *Response) Next() *Response {
-	if r.check() {
-		return r
+// xmlDeclEncoding 返回 XML 声明中的编码，没有时返回空字符串
+func xmlDeclEncoding(body []byte) string {
+	if len(body) > 1024 {
+		body = body[:1024]
 	}
-	if !r.ensureNodes() {
-		return r
+	if m := xmlDeclPattern.FindSubmatch(body); m != nil {
+		return string(m[1])
 	}
-	var nexts []*html.Node
-	for _, node := range r.curNodes {
-		for n := node.NextSibling; n != nil; n = n.NextSibling {
-			if n.Type == html.ElementNode {
-				nexts = append(nexts, n)
-				break
-			}
-		}
-	}
-	r.curNodes = nexts
-	return r
+	return ""
 }
 
-// Prev 将当前节点集合替换为每个节点的上一个兄弟元素节点（仅第一个）。
-func (r *Response) Prev() *Response {
-	if r.check() {
-		return r
-	}
-	if !r.ensureNodes() {
-		return r
-	}
-	var prevs []*html.Node
-	for _, node := range r.curNodes {
-		for n := node.PrevSibling; n != nil; n = n.PrevSibling {
-			if n.Type == html.ElementNode {
-				prevs = append(prevs, n)
-				break
-			}
+// registerDeclaredNamespaces 注册文档中 xmlns:prefix 声明的命名空间，不覆盖已注册的前缀
+func registerDeclaredNamespaces(n *xmlquery.Node, ns *xmlNamespaces) {
+	for _, attr := range n.Attr {
+		if attr.Name.Space == "xmlns" && attr.Name.Local != "" {
+			ns.set(attr.Name.Local, attr.Value, false)
 		}
 	}
-	r.curNodes = prevs
-	return r
-}
-
-// Each 遍历当前节点集合，对每个节点执行 fn。
-func (r *Response) Each(fn func(int, *html.Node)) *Response {
-	if r.check() {
-		return r
-	}
-	for i, node := range r.curNodes {
-		fn(i, node)
-	}
-	return r
-}
-
-// Map 将当前节点集合映射为字符串切片，每个元素由 fn 生成。
-func (r *Response) Map(fn func(*html.Node) string) []string {
-	if r.check() || len(r.curNodes) == 0 {
-		return nil
-	}
-	result := make([]string, len(r.curNodes))
-	for i, node := range r.curNodes {
-		result[i] = fn(node)
-	}
-	return result
-}
-
-// Length 返回当前节点集合中的元素个数。
-func (r *Response) Length() int {
-	if r.check() {
-		return 0
-	}
-	return len(r.curNodes)
-}
-
-// Error 返回链式调用过程中遇到的第一个错误，如果没有错误则返回 nil。
-func (r *Response) Error() error {
-	return r.err
-}
-
-// Reset 重置当前节点集合为空，并清空错误，但保留已解析的根节点（可用于开始新的查询）。
-func (r *Response) Reset() *Response {
-	r.curNodes = nil
-	r.err = nil
-	return r
-}
-
-// Clone 返回一个当前 Response 的浅拷贝，可用于分支链式调用。
-// 克隆后的 Response 共享相同的根节点和当前节点集合（但 curNodes 切片是独立的），
-// 因此对一个副本的查询不会影响另一个。
-func (r *Response) Clone() *Response {
-	clone := *r
-	// 复制 curNodes 切片，避免相互影响
-	if r.curNodes != nil {
-		clone.curNodes = make([]*html.Node, len(r.curNodes))
-		copy(clone.curNodes, r.curNodes)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == xmlquery.ElementNode {
+			registerDeclaredNamespaces(c, ns)
+		}
 	}
-	return &clone
 }
@@ -0,0 +1,540 @@
+package httpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"golang.org/x/net/html"
+)
+
+// Selector 包装一个解析后的节点，类似 Parsel 的 Selector。
+// Selector 是不可变的：所有查询都返回新的 SelectorList，不会修改自身或所属的 Response，
+// 因此可以在多个 goroutine 中同时查询同一个 Response。
+//
+// 除了元素节点，Selector 也可以表示字符串值：::attr(name)、XPath 的 @attr
+// 以及 string()/count() 等函数的结果，此时 Get 返回该字符串，继续查询得到空列表。
+//
+// XML 模式下 Selector 包装 XML 节点（见 XMLNode），只支持 XPath 查询，
+// 带前缀的名称按 Response.RegisterNamespace 和文档中声明的命名空间匹配。
+type Selector struct {
+	node    *html.Node
+	xnode   *xmlquery.Node // XML 模式下的节点
+	ns      *xmlNamespaces // XML 模式下 XPath 使用的命名空间
+	value   string         // 字符串值（属性值或 XPath 函数结果）
+	isValue bool           // 是否为字符串值
+	err     error          // 创建时的错误（例如 HTML 解析失败），会传递给查询结果
+}
+
+// newValueSelector 创建表示字符串值的选择器
+func newValueSelector(value string) *Selector {
+	return &Selector{value: value, isValue: true}
+}
+
+// NewSelector 使用任意 HTML 节点创建选择器
+func NewSelector(node *html.Node) *Selector {
+	return &Selector{node: node}
+}
+
+// NewXMLSelector 使用任意 XML 节点创建选择器，带前缀的 XPath 名称按字面前缀匹配
+func NewXMLSelector(node *xmlquery.Node) *Selector {
+	return &Selector{xnode: node, ns: &xmlNamespaces{}}
+}
+
+// newXMLSelector 创建共享命名空间映射的 XML 选择器
+func newXMLSelector(node *xmlquery.Node, ns *xmlNamespaces) *Selector {
+	return &Selector{xnode: node, ns: ns}
+}
+
+// Node 返回底层 HTML 节点，字符串值选择器和 XML 选择器返回 nil
+func (s *Selector) Node() *html.Node {
+	return s.node
+}
+
+// XMLNode 返回底层 XML 节点，非 XML 选择器返回 nil
+func (s *Selector) XMLNode() *xmlquery.Node {
+	return s.xnode
+}
+
+// IsValue 报告选择器是否表示字符串值而不是节点
+func (s *Selector) IsValue() bool {
+	return s.isValue
+}
+
+// CSS 在当前节点下执行 CSS 选择器查询
+func (s *Selector) CSS(selector string) SelectorList {
+	return SelectorList{sels: []*Selector{s}}.CSS(selector)
+}
+
+// XPath 在当前节点下执行 XPath 查询
+func (s *Selector) XPath(expr string) SelectorList {
+	return SelectorList{sels: []*Selector{s}}.XPath(expr)
+}
+
+// Get 返回节点的序列化结果：元素节点为 HTML 源码，文本节点为文本内容，
+// 字符串值选择器为值本身
+func (s *Selector) Get() string {
+	if s.isValue {
+		return s.value
+	}
+	if s.xnode != nil {
+		switch s.xnode.Type {
+		case xmlquery.TextNode, xmlquery.CharDataNode:
+			return s.xnode.Data
+		}
+		return s.xnode.OutputXML(true)
+	}
+	if s.node == nil {
+		return ""
+	}
+	if s.node.Type == html.TextNode {
+		return s.node.Data
+	}
+	var buf bytes.Buffer
+	if err := html.Render(&buf, s.node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Attr 返回节点的属性值，属性不存在时返回空字符串
+func (s *Selector) Attr(name string) string {
+	if s.xnode != nil {
+		return s.xnode.SelectAttr(name)
+	}
+	if s.node == nil {
+		return ""
+	}
+	for _, attr := range s.node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// Text 返回节点内的全部文本，去除首尾空白
+func (s *Selector) Text() string {
+	if s.isValue {
+		return strings.TrimSpace(s.value)
+	}
+	if s.xnode != nil {
+		return strings.TrimSpace(s.xnode.InnerText())
+	}
+	if s.node == nil {
+		return ""
+	}
+	return strings.TrimSpace(htmlquery.InnerText(s.node))
+}
+
+// Re 对 Get() 的结果执行正则匹配，规则同 SelectorList.Re
+func (s *Selector) Re(pattern string) ([]string, error) {
+	return SelectorList{sels: []*Selector{s}}.Re(pattern)
+}
+
+// SelectorList 选择器列表。所有方法都返回新的列表，原列表保持不变。
+// 链式调用中遇到的第一个错误会一直向后传递，可通过 Error 获取。
+type SelectorList struct {
+	sels []*Selector
+	err  error
+}
+
+// All 返回列表中的全部选择器
+func (l SelectorList) All() []*Selector {
+	return l.sels
+}
+
+// Error 返回链式调用过程中遇到的第一个错误
+func (l SelectorList) Error() error {
+	return l.err
+}
+
+// Length 返回列表中的选择器个数
+func (l SelectorList) Length() int {
+	return len(l.sels)
+}
+
+// derive 以相同的错误状态创建新列表
+func (l SelectorList) derive(sels []*Selector) SelectorList {
+	return SelectorList{sels: sels, err: l.err}
+}
+
+// CSS 对列表中每个节点执行 CSS 查询，合并结果。
+// 支持 Scrapy 风格的伪元素：
+//
+//	a::text         a 的直接子文本节点
+//	div ::text      div 下所有后代文本节点
+//	a::attr(href)   a 的 href 属性值
+//	::text          当前节点的直接子文本节点
+func (l SelectorList) CSS(selector string) SelectorList {
+	if l.err != nil {
+		return l
+	}
+	query, err := compileCSS(selector)
+	if err != nil {
+		return SelectorList{err: fmt.Errorf("invalid CSS selector: %v", err)}
+	}
+
+	var results []*Selector
+	for _, s := range l.sels {
+		if s.err != nil {
+			return SelectorList{err: s.err}
+		}
+		if s.xnode != nil {
+			return SelectorList{err: errors.New("XML 文档不支持 CSS 选择器，请使用 XPath")}
+		}
+		if s.node == nil {
+			continue
+		}
+		results = append(results, query.selectFrom(s.node)...)
+	}
+	return l.derive(results)
+}
+
+// Find 是 CSS 的别名，提供类似 jQuery 的语义
+func (l SelectorList) Find(selector string) SelectorList {
+	return l.CSS(selector)
+}
+
+// XPath 对列表中每个节点执行 XPath 查询，合并结果
+func (l SelectorList) XPath(expr string) SelectorList {
+	if l.err != nil {
+		return l
+	}
+
+	var results []*Selector
+	for _, s := range l.sels {
+		if s.err != nil {
+			return SelectorList{err: s.err}
+		}
+		var found []*Selector
+		var err error
+		switch {
+		case s.xnode != nil:
+			found, err = evalXMLXPath(s.xnode, expr, s.ns)
+		case s.node != nil:
+			found, err = evalXPath(s.node, expr)
+		default:
+			continue
+		}
+		if err != nil {
+			return SelectorList{err: fmt.Errorf("invalid XPath expression: %v", err)}
+		}
+		results = append(results, found...)
+	}
+	return l.derive(results)
+}
+
+// First 返回只包含第一个元素的列表
+func (l SelectorList) First() SelectorList {
+	return l.Eq(0)
+}
+
+// Last 返回只包含最后一个元素的列表
+func (l SelectorList) Last() SelectorList {
+	return l.Eq(len(l.sels) - 1)
+}
+
+// Eq 返回只包含索引 i 处元素的列表（从 0 开始），索引越界时返回空列表
+func (l SelectorList) Eq(i int) SelectorList {
+	if i < 0 || i >= len(l.sels) {
+		return l.derive(nil)
+	}
+	return l.derive([]*Selector{l.sels[i]})
+}
+
+// Filter 保留使 fn 返回 true 的选择器
+func (l SelectorList) Filter(fn func(*Selector) bool) SelectorList {
+	var filtered []*Selector
+	for _, s := range l.sels {
+		if fn(s) {
+			filtered = append(filtered, s)
+		}
+	}
+	return l.derive(filtered)
+}
+
+// Children 返回每个节点的子元素节点
+func (l SelectorList) Children() SelectorList {
+	var children []*Selector
+	for _, s := range l.sels {
+		children = append(children, s.children()...)
+	}
+	return l.derive(children)
+}
+
+// Parent 返回每个节点的父元素节点（去重）
+func (l SelectorList) Parent() SelectorList {
+	seen := make(map[any]bool)
+	var parents []*Selector
+	for _, s := range l.sels {
+		p := s.parent()
+		if p == nil {
+			continue
+		}
+		key := any(p.node)
+		if p.xnode != nil {
+			key = p.xnode
+		}
+		if !seen[key] {
+			seen[key] = true
+			parents = append(parents, p)
+		}
+	}
+	return l.derive(parents)
+}
+
+// Next 返回每个节点的下一个兄弟元素节点
+func (l SelectorList) Next() SelectorList {
+	var nexts []*Selector
+	for _, s := range l.sels {
+		if n := s.sibling(true); n != nil {
+			nexts = append(nexts, n)
+		}
+	}
+	return l.derive(nexts)
+}
+
+// Prev 返回每个节点的上一个兄弟元素节点
+func (l SelectorList) Prev() SelectorList {
+	var prevs []*Selector
+	for _, s := range l.sels {
+		if p := s.sibling(false); p != nil {
+			prevs = append(prevs, p)
+		}
+	}
+	return l.derive(prevs)
+}
+
+// children 返回子元素节点
+func (s *Selector) children() []*Selector {
+	var children []*Selector
+	switch {
+	case s.xnode != nil:
+		for child := s.xnode.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == xmlquery.ElementNode {
+				children = append(children, newXMLSelector(child, s.ns))
+			}
+		}
+	case s.node != nil:
+		for child := s.node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode {
+				children = append(children, &Selector{node: child})
+			}
+		}
+	}
+	return children
+}
+
+// parent 返回父元素节点，没有时返回 nil
+func (s *Selector) parent() *Selector {
+	switch {
+	case s.xnode != nil:
+		if p := s.xnode.Parent; p != nil && p.Type == xmlquery.ElementNode {
+			return newXMLSelector(p, s.ns)
+		}
+	case s.node != nil:
+		if p := s.node.Parent; p != nil && p.Type == html.ElementNode {
+			return &Selector{node: p}
+		}
+	}
+	return nil
+}
+
+// sibling 返回下一个（next 为 true）或上一个兄弟元素节点，没有时返回 nil
+func (s *Selector) sibling(next bool) *Selector {
+	switch {
+	case s.xnode != nil:
+		n := s.xnode
+		for {
+			if next {
+				n = n.NextSibling
+			} else {
+				n = n.PrevSibling
+			}
+			if n == nil {
+				return nil
+			}
+			if n.Type == xmlquery.ElementNode {
+				return newXMLSelector(n, s.ns)
+			}
+		}
+	case s.node != nil:
+		n := s.node
+		for {
+			if next {
+				n = n.NextSibling
+			} else {
+				n = n.PrevSibling
+			}
+			if n == nil {
+				return nil
+			}
+			if n.Type == html.ElementNode {
+				return &Selector{node: n}
+			}
+		}
+	}
+	return nil
+}
+
+// Each 遍历列表，对每个选择器执行 fn
+func (l SelectorList) Each(fn func(int, *Selector)) SelectorList {
+	for i, s := range l.sels {
+		fn(i, s)
+	}
+	return l
+}
+
+// Map 将列表映射为字符串切片
+func (l SelectorList) Map(fn func(*Selector) string) []string {
+	if len(l.sels) == 0 {
+		return nil
+	}
+	result := make([]string, len(l.sels))
+	for i, s := range l.sels {
+		result[i] = fn(s)
+	}
+	return result
+}
+
+// Get 返回第一个选择器的 Get() 结果，列表为空时返回 def（默认空字符串）
+func (l SelectorList) Get(def ...string) string {
+	if len(l.sels) == 0 {
+		if len(def) > 0 {
+			return def[0]
+		}
+		return ""
+	}
+	return l.sels[0].Get()
+}
+
+// GetAll 返回所有选择器的 Get() 结果
+func (l SelectorList) GetAll() []string {
+	result := make([]string, 0, len(l.sels))
+	for _, s := range l.sels {
+		result = append(result, s.Get())
+	}
+	return result
+}
+
+// Attr 返回第一个节点的属性值
+func (l SelectorList) Attr(name string) string {
+	if len(l.sels) == 0 {
+		return ""
+	}
+	return l.sels[0].Attr(name)
+}
+
+// Attrs 返回所有拥有该属性的节点的属性值，等价于 CSS("::attr(name)").GetAll() 作用于当前节点
+func (l SelectorList) Attrs(name string) []string {
+	result := make([]string, 0, len(l.sels))
+	for _, s := range l.sels {
+		switch {
+		case s.xnode != nil:
+			if s.xnode.HasAttr(name) {
+				result = append(result, s.xnode.SelectAttr(name))
+			}
+		case s.node != nil:
+			if val, ok := attrValue(s.node, name); ok {
+				result = append(result, val)
+			}
+		}
+	}
+	return result
+}
+
+// Text 返回所有节点的文本内容（多个节点用换行分隔），并去除首尾空白
+func (l SelectorList) Text() (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+	texts := make([]string, 0, len(l.sels))
+	for _, s := range l.sels {
+		texts = append(texts, s.Text())
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// MustText 返回 Text 的结果，忽略错误
+func (l SelectorList) MustText() string {
+	s, _ := l.Text()
+	return s
+}
+
+// HTML 返回所有节点的 HTML 源码拼接（多个节点用换行分隔）
+func (l SelectorList) HTML() (string, error) {
+	if l.err != nil {
+		return "", l.err
+	}
+	if len(l.sels) == 1 {
+		return l.sels[0].Get(), nil
+	}
+	var buf strings.Builder
+	for _, s := range l.sels {
+		buf.WriteString(s.Get())
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
+
+// MustHTML 返回 HTML 的结果，忽略错误
+func (l SelectorList) MustHTML() string {
+	s, _ := l.HTML()
+	return s
+}
+
+// Re 对每个选择器的 Get() 结果执行正则匹配，规则与 Parsel 一致：
+// 存在名为 extract 的分组时返回该分组；没有分组时返回整个匹配；
+// 否则返回所有分组的内容。
+func (l SelectorList) Re(pattern string) ([]string, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	re, err := CompileRegex(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, s := range l.sels {
+		results = append(results, reExtract(re, s.Get())...)
+	}
+	return results, nil
+}
+
+// ReFirst 返回 Re 的第一个结果，没有匹配时返回 def（默认空字符串）
+func (l SelectorList) ReFirst(pattern string, def ...string) (string, error) {
+	matches, err := l.Re(pattern)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		if len(def) > 0 {
+			return def[0], nil
+		}
+		return "", nil
+	}
+	return matches[0], nil
+}
+
+// reExtract 按 Parsel 的规则提取匹配内容
+func reExtract(re *regexp.Regexp, text string) []string {
+	var results []string
+	if idx := re.SubexpIndex("extract"); idx > 0 {
+		for _, m := range re.FindAllStringSubmatch(text, -1) {
+			results = append(results, m[idx])
+		}
+		return results
+	}
+	if re.NumSubexp() == 0 {
+		return re.FindAllString(text, -1)
+	}
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		results = append(results, m[1:]...)
+	}
+	return results
+}
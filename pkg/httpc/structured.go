@@ -0,0 +1,551 @@
+package httpc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/item"
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/html"
+)
+
+// 结构化数据来源
+const (
+	SourceJSONLD    = "json-ld"
+	SourceMicrodata = "microdata"
+	SourceRDFa      = "rdfa"
+)
+
+// StructuredEntity 结构化数据中的一个实体（JSON-LD 对象、Microdata itemscope 或 RDFa typeof）。
+// 属性值为 string 或 *StructuredEntity，标量统一为字符串；类型和属性名去掉 schema.org 前缀。
+type StructuredEntity struct {
+	Type       []string
+	ID         string
+	Properties map[string][]any
+	Source     string
+}
+
+// StructuredData 页面中的全部结构化数据
+type StructuredData struct {
+	JSONLD    []*StructuredEntity
+	Microdata []*StructuredEntity
+	RDFa      []*StructuredEntity
+	OpenGraph map[string][]string // og:*、article:*、product:* 等 <meta property>
+	Twitter   map[string][]string // twitter:* 卡片
+}
+
+// StructuredData 解析页面中的 JSON-LD、Microdata、RDFa 以及 OpenGraph / Twitter 卡片。
+// 无法解析的 JSON-LD 块会被跳过。
+func (r *Response) StructuredData() (*StructuredData, error) {
+	root := r.Selector()
+	if root.Node() == nil {
+		if err := r.Error(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("结构化数据只支持 HTML 文档")
+	}
+	base, err := r.BaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &StructuredData{
+		OpenGraph: make(map[string][]string),
+		Twitter:   make(map[string][]string),
+	}
+	for _, block := range r.JSONLD() {
+		data.JSONLD = append(data.JSONLD, jsonLDEntities(block)...)
+	}
+
+	sp := &structuredParser{base: base, ids: make(map[string]*html.Node)}
+	sp.indexIDs(root.Node())
+	sp.walk(root.Node(), data)
+	return data, nil
+}
+
+// Entities 返回所有来源的顶层实体
+func (d *StructuredData) Entities() []*StructuredEntity {
+	all := make([]*StructuredEntity, 0, len(d.JSONLD)+len(d.Microdata)+len(d.RDFa))
+	all = append(all, d.JSONLD...)
+	all = append(all, d.Microdata...)
+	return append(all, d.RDFa...)
+}
+
+// FindType 返回指定类型的实体（包括嵌套实体），按 JSON-LD、Microdata、RDFa 的顺序
+func (d *StructuredData) FindType(schemaType string) []*StructuredEntity {
+	var found []*StructuredEntity
+	var visit func(*StructuredEntity)
+	visit = func(e *StructuredEntity) {
+		if e.Is(schemaType) {
+			found = append(found, e)
+		}
+		for _, values := range e.Properties {
+			for _, v := range values {
+				if child, ok := v.(*StructuredEntity); ok {
+					visit(child)
+				}
+			}
+		}
+	}
+	for _, e := range d.Entities() {
+		visit(e)
+	}
+	return found
+}
+
+// Item 将第一个指定类型的实体转换为 StrictItem，fields 的含义同 StructuredEntity.ToItem
+func (d *StructuredData) Item(schemaType string, fields map[string]string) (*item.StrictItem, error) {
+	entities := d.FindType(schemaType)
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("页面中没有 %s 类型的结构化数据", schemaType)
+	}
+	return entities[0].ToItem(fields)
+}
+
+// OG 返回 OpenGraph 属性的第一个值，key 可省略 og: 前缀
+func (d *StructuredData) OG(key string) string {
+	if !strings.Contains(key, ":") {
+		key = "og:" + key
+	}
+	if v := d.OpenGraph[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Is 判断实体是否为指定类型（不区分 schema.org 前缀）
+func (e *StructuredEntity) Is(schemaType string) bool {
+	schemaType = shortName(schemaType)
+	for _, t := range e.Type {
+		if t == schemaType {
+			return true
+		}
+	}
+	return false
+}
+
+// Values 按点分路径返回全部值，如 "offers.price"，中间步骤展开所有嵌套实体
+func (e *StructuredEntity) Values(path string) []any {
+	current := []*StructuredEntity{e}
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		var values []any
+		for _, ent := range current {
+			values = append(values, ent.Properties[key]...)
+		}
+		if i == len(keys)-1 {
+			return values
+		}
+		current = current[:0:0]
+		for _, v := range values {
+			if child, ok := v.(*StructuredEntity); ok {
+				current = append(current, child)
+			}
+		}
+	}
+	return nil
+}
+
+// String 返回路径上的第一个值，嵌套实体返回其 name 或 @id
+func (e *StructuredEntity) String(path string) string {
+	values := e.Values(path)
+	if len(values) == 0 {
+		return ""
+	}
+	switch v := values[0].(type) {
+	case string:
+		return v
+	case *StructuredEntity:
+		if name := v.String("name"); name != "" {
+			return name
+		}
+		return v.ID
+	}
+	return ""
+}
+
+// Entity 返回路径上的第一个嵌套实体，没有时返回 nil
+func (e *StructuredEntity) Entity(path string) *StructuredEntity {
+	for _, v := range e.Values(path) {
+		if child, ok := v.(*StructuredEntity); ok {
+			return child
+		}
+	}
+	return nil
+}
+
+// ToMap 将实体转换为普通 map：单个值为标量，多个值为切片，嵌套实体递归转换
+func (e *StructuredEntity) ToMap() map[string]any {
+	m := make(map[string]any, len(e.Properties)+2)
+	if len(e.Type) == 1 {
+		m["@type"] = e.Type[0]
+	} else if len(e.Type) > 1 {
+		m["@type"] = e.Type
+	}
+	if e.ID != "" {
+		m["@id"] = e.ID
+	}
+	for k, values := range e.Properties {
+		m[k] = plainValues(values)
+	}
+	return m
+}
+
+// ToItem 将实体转换为 StrictItem。fields 将项目字段映射到属性路径，如
+// {"price": "offers.price", "brand": "brand.name"}；为 nil 时使用全部顶层属性，字段名即属性名。
+// 单个值为字符串（嵌套实体为 map），多个值为切片，路径不存在的字段不设置。
+func (e *StructuredEntity) ToItem(fields map[string]string) (*item.StrictItem, error) {
+	if fields == nil {
+		fields = make(map[string]string, len(e.Properties))
+		for k := range e.Properties {
+			fields[k] = k
+		}
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+
+	it := item.NewStrictItem(names)
+	for field, path := range fields {
+		values := e.Values(path)
+		if len(values) == 0 {
+			continue
+		}
+		if err := it.Set(field, plainValues(values)); err != nil {
+			return nil, err
+		}
+	}
+	return it, nil
+}
+
+// plainValues 将属性值转换为普通值
+func plainValues(values []any) any {
+	plain := make([]any, len(values))
+	for i, v := range values {
+		if child, ok := v.(*StructuredEntity); ok {
+			plain[i] = child.ToMap()
+		} else {
+			plain[i] = v
+		}
+	}
+	if len(plain) == 1 {
+		return plain[0]
+	}
+	return plain
+}
+
+// addProperty 追加属性值
+func (e *StructuredEntity) addProperty(name string, value any) {
+	name = shortName(name)
+	if name == "" {
+		return
+	}
+	if e.Properties == nil {
+		e.Properties = make(map[string][]any)
+	}
+	e.Properties[name] = append(e.Properties[name], value)
+}
+
+// shortName 去掉 schema.org 等词汇表前缀，如 "https://schema.org/Product" -> "Product"、"schema:name" -> "name"
+func shortName(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexAny(s, "/#"); i >= 0 && strings.Contains(s, "://") {
+		return s[i+1:]
+	}
+	if prefix, local, ok := strings.Cut(s, ":"); ok && (prefix == "schema" || prefix == "sdo") {
+		return local
+	}
+	return s
+}
+
+// jsonLDEntities 将一个 JSON-LD 块转换为实体，@graph 中的对象作为顶层实体
+func jsonLDEntities(block gjson.Result) []*StructuredEntity {
+	if !block.IsObject() {
+		return nil
+	}
+	if graph := block.Get(`\@graph`); graph.IsArray() {
+		var entities []*StructuredEntity
+		for _, node := range graph.Array() {
+			if node.IsObject() {
+				entities = append(entities, jsonLDEntity(node))
+			}
+		}
+		return entities
+	}
+	return []*StructuredEntity{jsonLDEntity(block)}
+}
+
+// jsonLDEntity 转换单个 JSON-LD 对象
+func jsonLDEntity(obj gjson.Result) *StructuredEntity {
+	e := &StructuredEntity{Source: SourceJSONLD, Properties: make(map[string][]any)}
+	obj.ForEach(func(k, v gjson.Result) bool {
+		switch key := k.String(); key {
+		case "@context":
+		case "@type":
+			for _, t := range jsonLDList(v) {
+				e.Type = append(e.Type, shortName(t.String()))
+			}
+		case "@id":
+			e.ID = v.String()
+		default:
+			for _, elem := range jsonLDList(v) {
+				if value, ok := jsonLDValue(elem); ok {
+					e.addProperty(key, value)
+				}
+			}
+		}
+		return true
+	})
+	return e
+}
+
+// jsonLDList 将值展开为列表
+func jsonLDList(v gjson.Result) []gjson.Result {
+	if v.IsArray() {
+		return v.Array()
+	}
+	return []gjson.Result{v}
+}
+
+// jsonLDValue 转换属性值：对象为嵌套实体（{"@value": ...} 取值本身），标量为字符串
+func jsonLDValue(v gjson.Result) (any, bool) {
+	switch {
+	case v.Type == gjson.Null:
+		return nil, false
+	case v.IsObject():
+		if val := v.Get(`\@value`); val.Exists() {
+			return val.String(), true
+		}
+		return jsonLDEntity(v), true
+	}
+	return v.String(), true
+}
+
+// structuredParser 解析 Microdata、RDFa 和 meta 标签
+type structuredParser struct {
+	base *url.URL
+	ids  map[string]*html.Node // itemref 引用的元素
+}
+
+// indexIDs 记录带 id 的元素
+func (sp *structuredParser) indexIDs(n *html.Node) {
+	if n.Type == html.ElementNode {
+		if id, ok := attrValue(n, "id"); ok && id != "" {
+			if _, exists := sp.ids[id]; !exists {
+				sp.ids[id] = n
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.indexIDs(c)
+	}
+}
+
+// walk 查找顶层 itemscope / typeof 以及 meta 标签
+func (sp *structuredParser) walk(n *html.Node, data *StructuredData) {
+	if n.Type == html.ElementNode {
+		_, scope := attrValue(n, "itemscope")
+		_, isProp := attrValue(n, "itemprop")
+		typeOf, hasTypeOf := attrValue(n, "typeof")
+		_, rdfaProp := attrValue(n, "property")
+
+		switch {
+		case scope && !isProp:
+			data.Microdata = append(data.Microdata, sp.microdataEntity(n))
+		case hasTypeOf && typeOf != "" && !rdfaProp:
+			data.RDFa = append(data.RDFa, sp.rdfaEntity(n))
+		}
+		if n.Data == "meta" {
+			sp.meta(n, data)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.walk(c, data)
+	}
+}
+
+// meta 收集 OpenGraph 和 Twitter 卡片
+func (sp *structuredParser) meta(n *html.Node, data *StructuredData) {
+	content, ok := attrValue(n, "content")
+	if !ok {
+		return
+	}
+	key, _ := attrValue(n, "property")
+	if key == "" {
+		key, _ = attrValue(n, "name")
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	prefix, _, found := strings.Cut(key, ":")
+	if !found {
+		return
+	}
+	switch prefix {
+	case "twitter":
+		data.Twitter[key] = append(data.Twitter[key], content)
+	case "og", "article", "book", "profile", "product", "music", "video", "fb":
+		data.OpenGraph[key] = append(data.OpenGraph[key], content)
+	}
+}
+
+// microdataEntity 解析 itemscope 元素
+func (sp *structuredParser) microdataEntity(n *html.Node) *StructuredEntity {
+	e := &StructuredEntity{Source: SourceMicrodata, Properties: make(map[string][]any)}
+	if types, ok := attrValue(n, "itemtype"); ok {
+		for _, t := range strings.Fields(types) {
+			e.Type = append(e.Type, shortName(t))
+		}
+	}
+	e.ID, _ = attrValue(n, "itemid")
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.microdataProps(c, e)
+	}
+	if refs, ok := attrValue(n, "itemref"); ok {
+		for _, id := range strings.Fields(refs) {
+			if ref := sp.ids[id]; ref != nil {
+				sp.microdataProps(ref, e)
+			}
+		}
+	}
+	return e
+}
+
+// microdataProps 收集 n 及其后代中的 itemprop，不进入嵌套的 itemscope
+func (sp *structuredParser) microdataProps(n *html.Node, e *StructuredEntity) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	_, scope := attrValue(n, "itemscope")
+	if names, ok := attrValue(n, "itemprop"); ok {
+		var value any
+		if scope {
+			value = sp.microdataEntity(n)
+		} else {
+			value = sp.microdataValue(n)
+		}
+		for _, name := range strings.Fields(names) {
+			e.addProperty(name, value)
+		}
+	}
+	if scope {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.microdataProps(c, e)
+	}
+}
+
+// microdataValue 按 HTML 规范取 itemprop 的值
+func (sp *structuredParser) microdataValue(n *html.Node) string {
+	if v, ok := attrValue(n, "content"); ok {
+		return v
+	}
+	switch n.Data {
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return sp.resolve(n, "src")
+	case "a", "area", "link":
+		return sp.resolve(n, "href")
+	case "object":
+		return sp.resolve(n, "data")
+	case "data", "meter":
+		v, _ := attrValue(n, "value")
+		return v
+	case "time":
+		if v, ok := attrValue(n, "datetime"); ok {
+			return v
+		}
+	}
+	return collapseSpace(htmlText(n))
+}
+
+// rdfaEntity 解析 typeof 元素
+func (sp *structuredParser) rdfaEntity(n *html.Node) *StructuredEntity {
+	e := &StructuredEntity{Source: SourceRDFa, Properties: make(map[string][]any)}
+	if types, ok := attrValue(n, "typeof"); ok {
+		for _, t := range strings.Fields(types) {
+			e.Type = append(e.Type, shortName(t))
+		}
+	}
+	if id, ok := attrValue(n, "resource"); ok {
+		e.ID = id
+	} else if id, ok := attrValue(n, "about"); ok {
+		e.ID = id
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.rdfaProps(c, e)
+	}
+	return e
+}
+
+// rdfaProps 收集 property 属性，带 typeof 的 property 元素作为嵌套实体
+func (sp *structuredParser) rdfaProps(n *html.Node, e *StructuredEntity) {
+	if n.Type != html.ElementNode {
+		return
+	}
+	typeOf, hasTypeOf := attrValue(n, "typeof")
+	nested := hasTypeOf && typeOf != ""
+	if names, ok := attrValue(n, "property"); ok {
+		var value any
+		if nested {
+			value = sp.rdfaEntity(n)
+		} else {
+			value = sp.rdfaValue(n)
+		}
+		for _, name := range strings.Fields(names) {
+			e.addProperty(name, value)
+		}
+	}
+	if nested {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sp.rdfaProps(c, e)
+	}
+}
+
+// rdfaValue 取 RDFa property 的值：content 优先，其次是链接，最后是文本
+func (sp *structuredParser) rdfaValue(n *html.Node) string {
+	if v, ok := attrValue(n, "content"); ok {
+		return v
+	}
+	for _, attr := range []string{"resource", "href", "src"} {
+		if _, ok := attrValue(n, attr); ok {
+			return sp.resolve(n, attr)
+		}
+	}
+	return collapseSpace(htmlText(n))
+}
+
+// resolve 读取链接属性并解析为绝对地址
+func (sp *structuredParser) resolve(n *html.Node, attr string) string {
+	v, _ := attrValue(n, attr)
+	v = strings.TrimSpace(v)
+	ref, err := url.Parse(v)
+	if err != nil || v == "" {
+		return v
+	}
+	return sp.base.ResolveReference(ref).String()
+}
+
+// htmlText 返回节点内的全部文本
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "script" || c.Data == "style") {
+			continue
+		}
+		b.WriteString(htmlText(c))
+	}
+	return b.String()
+}
+
+// collapseSpace 压缩连续空白并去除首尾空白
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,345 @@
+package httpc
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/item"
+	"golang.org/x/net/html"
+)
+
+// maxTableSpan rowspan / colspan 的上限，防止异常页面生成巨大的表格
+const maxTableSpan = 1000
+
+// TableCell 表格中的一个单元格
+type TableCell struct {
+	Text    string   // 单元格文本，连续空白压缩为一个空格
+	Links   []string // 单元格内链接的绝对地址
+	Header  bool     // 是否为 <th>
+	RowSpan int      // 实际跨越的行数：rowspan="0" 与超出行组的 rowspan 截断到所在行组（thead/tbody/tfoot）的末尾
+	ColSpan int
+	Spanned bool // 由 rowspan / colspan 展开得到的副本，不是原始单元格
+}
+
+// Table 展开 rowspan / colspan 之后的表格，每行的单元格数相同
+type Table struct {
+	Caption   string
+	HasHeader bool          // 是否识别到表头
+	Headers   []string      // 列名，没有表头时为 col1、col2……
+	Rows      [][]TableCell // 数据行（<tbody> 以及没有分组的行）
+	Footer    [][]TableCell // <tfoot> 中的行
+	Selector  *Selector     // <table> 元素
+}
+
+// tableRow 解析过程中的行
+type tableRow struct {
+	node    *html.Node
+	section string // thead / tbody / tfoot
+	group   int    // 所在行组的序号，rowspan 不会跨越行组
+}
+
+// Tables 解析页面中的表格，selector 默认为 "table"，嵌套表格会作为独立的表格返回。
+// 表头取自 <thead>，没有 <thead> 时取开头全部由 <th> 组成的行；多行表头按列合并为一个名称。
+func (r *Response) Tables(selector ...string) ([]*Table, error) {
+	sel := "table"
+	if len(selector) > 0 && selector[0] != "" {
+		sel = selector[0]
+	}
+	list := r.CSS(sel)
+	if err := list.Error(); err != nil {
+		return nil, err
+	}
+	base, err := r.BaseURL()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*Table
+	for _, s := range list.All() {
+		if n := s.Node(); n != nil && n.Type == html.ElementNode && n.Data == "table" {
+			tables = append(tables, parseTable(s, base))
+		}
+	}
+	return tables, nil
+}
+
+// parseTable 解析单个表格
+func parseTable(s *Selector, base *url.URL) *Table {
+	t := &Table{Selector: s}
+	n := s.Node()
+
+	var rows []tableRow
+	group, bare := 0, false // bare: 上一个元素是直接位于 <table> 下的 <tr>，连续的此类行属于同一个行组
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		switch c.Data {
+		case "caption":
+			t.Caption = collapseSpace(htmlText(c))
+		case "thead", "tbody", "tfoot":
+			group++
+			for tr := c.FirstChild; tr != nil; tr = tr.NextSibling {
+				if tr.Type == html.ElementNode && tr.Data == "tr" {
+					rows = append(rows, tableRow{node: tr, section: c.Data, group: group})
+				}
+			}
+		case "tr":
+			if !bare {
+				group++
+			}
+			rows = append(rows, tableRow{node: c, section: "tbody", group: group})
+		}
+		bare = c.Data == "tr"
+	}
+
+	if len(rows) == 0 {
+		return t
+	}
+	grid := expandRows(rows, base)
+
+	// 表头：<thead> 中的行，否则为开头全部由 <th> 组成的行
+	headerRows := 0
+	for i, row := range rows {
+		if row.section == "thead" {
+			headerRows = i + 1
+		}
+	}
+	if headerRows == 0 {
+		for headerRows < len(grid) && rows[headerRows].section == "tbody" && allHeaderCells(grid[headerRows]) {
+			headerRows++
+		}
+	}
+	// 整个表格都是 <th> 时视为没有表头（例如纵向的键值表）
+	if headerRows == len(grid) && rows[len(rows)-1].section != "thead" {
+		headerRows = 0
+	}
+
+	width := 0
+	for _, row := range grid {
+		width = max(width, len(row))
+	}
+	t.HasHeader = headerRows > 0
+	t.Headers = headerNames(grid[:headerRows], width)
+
+	for i := headerRows; i < len(grid); i++ {
+		row := padRow(grid[i], width)
+		if rows[i].section == "tfoot" {
+			t.Footer = append(t.Footer, row)
+		} else {
+			t.Rows = append(t.Rows, row)
+		}
+	}
+	return t
+}
+
+// expandRows 按 HTML 表格模型展开 rowspan / colspan，rowspan 最多延伸到所在行组的末尾
+func expandRows(rows []tableRow, base *url.URL) [][]TableCell {
+	grid := make([][]TableCell, len(rows))
+	groupEnd := len(rows)
+	for r, row := range rows {
+		if r == 0 || row.group != rows[r-1].group {
+			groupEnd = r + 1
+			for groupEnd < len(rows) && rows[groupEnd].group == row.group {
+				groupEnd++
+			}
+		}
+		col := 0
+		for c := row.node.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.Data != "td" && c.Data != "th") {
+				continue
+			}
+			// 跳过被上方 rowspan 占用的位置
+			for col < len(grid[r]) && grid[r][col].ColSpan != 0 {
+				col++
+			}
+
+			cell := TableCell{
+				Text:    collapseSpace(htmlText(c)),
+				Links:   cellLinks(c, base),
+				Header:  c.Data == "th",
+				RowSpan: spanAttr(c, "rowspan"),
+				ColSpan: spanAttr(c, "colspan"),
+			}
+			if cell.RowSpan == 0 || cell.RowSpan > groupEnd-r {
+				cell.RowSpan = groupEnd - r
+			}
+			for dr := 0; dr < cell.RowSpan; dr++ {
+				for dc := 0; dc < cell.ColSpan; dc++ {
+					copied := cell
+					copied.Spanned = dr > 0 || dc > 0
+					setCell(&grid[r+dr], col+dc, copied)
+				}
+			}
+			col += cell.ColSpan
+		}
+	}
+	return grid
+}
+
+// setCell 在行的指定位置放置单元格，必要时扩展行
+func setCell(row *[]TableCell, col int, cell TableCell) {
+	for len(*row) <= col {
+		*row = append(*row, TableCell{})
+	}
+	(*row)[col] = cell
+}
+
+// padRow 将行补齐到指定宽度，未占用的位置为空单元格
+func padRow(row []TableCell, width int) []TableCell {
+	padded := make([]TableCell, width)
+	copy(padded, row)
+	for i := range padded {
+		if padded[i].ColSpan == 0 {
+			padded[i] = TableCell{RowSpan: 1, ColSpan: 1}
+		}
+	}
+	return padded
+}
+
+// spanAttr 读取 rowspan / colspan，无效时返回 1。rowspan="0" 表示延伸到行组末尾，返回 0
+func spanAttr(n *html.Node, name string) int {
+	v, _ := attrValue(n, name)
+	span, err := strconv.Atoi(strings.TrimSpace(v))
+	if err == nil && span == 0 && name == "rowspan" {
+		return 0
+	}
+	if err != nil || span < 1 {
+		return 1
+	}
+	return min(span, maxTableSpan)
+}
+
+// cellLinks 返回单元格内 <a href> 的绝对地址
+func cellLinks(n *html.Node, base *url.URL) []string {
+	var links []string
+	for _, a := range descendantElements(n) {
+		if a.Data != "a" {
+			continue
+		}
+		href, ok := attrValue(a, "href")
+		if !ok || strings.TrimSpace(href) == "" {
+			continue
+		}
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		links = append(links, base.ResolveReference(ref).String())
+	}
+	return links
+}
+
+// allHeaderCells 判断行中的单元格是否全部为 <th>
+func allHeaderCells(row []TableCell) bool {
+	if len(row) == 0 {
+		return false
+	}
+	for _, cell := range row {
+		if !cell.Header {
+			return false
+		}
+	}
+	return true
+}
+
+// headerNames 合并多行表头为列名，空列名为 colN，重复列名追加 _2、_3
+func headerNames(headerRows [][]TableCell, width int) []string {
+	names := make([]string, width)
+	seen := make(map[string]int)
+	for col := 0; col < width; col++ {
+		var parts []string
+		for _, row := range headerRows {
+			if col >= len(row) || row[col].Text == "" {
+				continue
+			}
+			if len(parts) == 0 || parts[len(parts)-1] != row[col].Text {
+				parts = append(parts, row[col].Text)
+			}
+		}
+		name := strings.Join(parts, " ")
+		if name == "" {
+			name = "col" + strconv.Itoa(col+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name += "_" + strconv.Itoa(seen[name])
+		}
+		names[col] = name
+	}
+	return names
+}
+
+// Column 返回指定列的全部文本，列不存在时返回 nil
+func (t *Table) Column(name string) []string {
+	idx := t.columnIndex(name)
+	if idx < 0 {
+		return nil
+	}
+	values := make([]string, len(t.Rows))
+	for i, row := range t.Rows {
+		values[i] = row[idx].Text
+	}
+	return values
+}
+
+// columnIndex 返回列名对应的下标
+func (t *Table) columnIndex(name string) int {
+	for i, h := range t.Headers {
+		if h == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Records 将每个数据行转换为以列名为键的 map
+func (t *Table) Records() []map[string]string {
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(t.Headers))
+		for i, h := range t.Headers {
+			record[h] = row[i].Text
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Items 将每个数据行转换为 StrictItem，字段名为列名，值为单元格文本。
+// rename 可将列名映射为字段名，只保留映射中的列；省略时保留全部列
+func (t *Table) Items(rename ...map[string]string) ([]*item.StrictItem, error) {
+	columns := make(map[int]string, len(t.Headers))
+	if len(rename) > 0 && rename[0] != nil {
+		for header, field := range rename[0] {
+			idx := t.columnIndex(header)
+			if idx < 0 {
+				return nil, errors.New("表格中没有列: " + header)
+			}
+			columns[idx] = field
+		}
+	} else {
+		for i, h := range t.Headers {
+			columns[i] = h
+		}
+	}
+
+	fields := make([]string, 0, len(columns))
+	for _, f := range columns {
+		fields = append(fields, f)
+	}
+
+	items := make([]*item.StrictItem, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		it := item.NewStrictItem(fields)
+		for idx, field := range columns {
+			if err := it.Set(field, row[idx].Text); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
@@ -0,0 +1,261 @@
+package httpc
+
+import (
+	"strings"
+	"testing"
+)
+
+// tableSummary 将表格概括为文本：表头一行，数据行以 R: 开头，表尾以 F: 开头，单元格以 | 分隔
+func tableSummary(t *Table) string {
+	var b strings.Builder
+	b.WriteString("H: " + strings.Join(t.Headers, ","))
+	if !t.HasHeader {
+		b.WriteString(" (none)")
+	}
+	row := func(prefix string, cells []TableCell) {
+		texts := make([]string, len(cells))
+		for i, c := range cells {
+			texts[i] = c.Text
+		}
+		b.WriteString("\n" + prefix + strings.Join(texts, "|"))
+	}
+	for _, r := range t.Rows {
+		row("R: ", r)
+	}
+	for _, r := range t.Footer {
+		row("F: ", r)
+	}
+	return b.String()
+}
+
+// parseTables 解析 HTML 片段中的全部表格
+func parseTables(t *testing.T, body string) []*Table {
+	t.Helper()
+	resp := NewResponse("http://example.com/list/", 200, map[string]string{"Content-Type": "text/html"}, []byte(body), New("http://example.com/list/"), "HTTP/1.1")
+	tables, err := resp.Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tables
+}
+
+func TestTables(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "thead",
+			html: `<table><thead><tr><th>Name</th><th>Age</th></tr></thead><tbody><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></tbody></table>`,
+			want: "H: Name,Age\nR: a|1\nR: b|2",
+		},
+		{
+			name: "leading th row as header",
+			html: `<table><tr><th>Name</th><th> Age </th></tr><tr><td>a</td><td>1</td></tr></table>`,
+			want: "H: Name,Age\nR: a|1",
+		},
+		{
+			name: "no header",
+			html: `<table><tr><td>a</td><td>1</td></tr></table>`,
+			want: "H: col1,col2 (none)\nR: a|1",
+		},
+		{
+			name: "rowspan across rows",
+			html: `<table><tr><th>A</th><th>B</th><th>C</th></tr>
+				<tr><td rowspan="2">x</td><td>1</td><td>2</td></tr>
+				<tr><td>3</td><td>4</td></tr>
+				<tr><td>y</td><td rowspan="3">5</td><td>6</td></tr>
+				<tr><td>z</td><td>7</td></tr></table>`,
+			want: "H: A,B,C\nR: x|1|2\nR: x|3|4\nR: y|5|6\nR: z|5|7",
+		},
+		{
+			name: "rowspan and colspan block",
+			html: `<table><tr><td rowspan="2" colspan="2">big</td><td>1</td></tr>
+				<tr><td>2</td></tr>
+				<tr><td>a</td><td>b</td><td>c</td></tr></table>`,
+			want: "H: col1,col2,col3 (none)\nR: big|big|1\nR: big|big|2\nR: a|b|c",
+		},
+		{
+			name: "key value table with th keys",
+			html: `<table><tr><th>Name</th><td>Duck</td></tr><tr><th>Age</th><td>3</td></tr></table>`,
+			want: "H: col1,col2 (none)\nR: Name|Duck\nR: Age|3",
+		},
+		{
+			name: "all th table has no header",
+			html: `<table><tr><th>Name</th><th>Duck</th></tr><tr><th>Age</th><th>3</th></tr></table>`,
+			want: "H: col1,col2 (none)\nR: Name|Duck\nR: Age|3",
+		},
+		{
+			name: "thead only",
+			html: `<table><thead><tr><th>A</th><th>B</th></tr></thead></table>`,
+			want: "H: A,B",
+		},
+		{
+			name: "multi row header",
+			html: `<table><thead>
+				<tr><th rowspan="2">Name</th><th colspan="2">Score</th></tr>
+				<tr><th>Math</th><th>Art</th></tr>
+				</thead><tbody><tr><td>a</td><td>90</td><td>80</td></tr></tbody></table>`,
+			want: "H: Name,Score Math,Score Art\nR: a|90|80",
+		},
+		{
+			name: "multi row th header without thead",
+			html: `<table><tr><th colspan="2">Price</th></tr><tr><th>Min</th><th>Max</th></tr><tr><td>1</td><td>2</td></tr></table>`,
+			want: "H: Price Min,Price Max\nR: 1|2",
+		},
+		{
+			name: "empty and duplicate header names",
+			html: `<table><tr><th>a</th><th></th><th>a</th><th>a</th></tr><tr><td>1</td><td>2</td><td>3</td><td>4</td></tr></table>`,
+			want: "H: a,col2,a_2,a_3\nR: 1|2|3|4",
+		},
+		{
+			name: "footer",
+			html: `<table><thead><tr><th>Item</th><th>Cost</th></tr></thead>
+				<tfoot><tr><td>Total</td><td>3</td></tr></tfoot>
+				<tbody><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></tbody></table>`,
+			want: "H: Item,Cost\nR: a|1\nR: b|2\nF: Total|3",
+		},
+		{
+			name: "ragged rows padded",
+			html: `<table><tr><td>a</td></tr><tr><td>b</td><td>c</td><td>d</td></tr></table>`,
+			want: "H: col1,col2,col3 (none)\nR: a||\nR: b|c|d",
+		},
+		{
+			name: "invalid spans",
+			html: `<table><tr><td colspan="x">a</td><td colspan="0">b</td><td rowspan="-1">c</td></tr><tr><td>1</td><td>2</td><td>3</td></tr></table>`,
+			want: "H: col1,col2,col3 (none)\nR: a|b|c\nR: 1|2|3",
+		},
+		{
+			name: "rowspan zero spans to end of group",
+			html: `<table><tbody><tr><td rowspan="0">x</td><td>1</td></tr><tr><td>2</td></tr><tr><td>3</td></tr></tbody>
+				<tbody><tr><td>y</td><td>4</td></tr></tbody></table>`,
+			want: "H: col1,col2 (none)\nR: x|1\nR: x|2\nR: x|3\nR: y|4",
+		},
+		{
+			name: "rowspan clipped to group",
+			html: `<table><thead><tr><th rowspan="3">H</th><th>I</th></tr></thead><tbody><tr><td>a</td><td>b</td></tr></tbody></table>`,
+			want: "H: H,I\nR: a|b",
+		},
+		{
+			name: "rowspan does not cross into footer",
+			html: `<table><tbody><tr><td rowspan="5">x</td><td>1</td></tr></tbody><tfoot><tr><td>f</td><td>2</td></tr></tfoot></table>`,
+			want: "H: col1,col2 (none)\nR: x|1\nF: f|2",
+		},
+		{
+			name: "whitespace collapsed",
+			html: "<table><tr><td>  a\n\t b  </td></tr></table>",
+			want: "H: col1 (none)\nR: a b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := parseTables(t, tt.html)
+			if len(tables) != 1 {
+				t.Fatalf("got %d tables, want 1", len(tables))
+			}
+			if got := tableSummary(tables[0]); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTablesEmpty(t *testing.T) {
+	for _, body := range []string{
+		`<table></table>`,
+		`<table><caption> Empty  table </caption></table>`,
+		`<table><tbody></tbody><tfoot></tfoot></table>`,
+		`<table><tr></tr></table>`,
+	} {
+		t.Run(body, func(t *testing.T) {
+			tables := parseTables(t, body)
+			if len(tables) != 1 {
+				t.Fatalf("got %d tables, want 1", len(tables))
+			}
+			tb := tables[0]
+			if tb.HasHeader || len(tb.Rows) > 1 || len(tb.Footer) != 0 {
+				t.Errorf("got %s", tableSummary(tb))
+			}
+			for _, row := range tb.Rows {
+				if len(row) != 0 {
+					t.Errorf("got %s", tableSummary(tb))
+				}
+			}
+			if len(tb.Records()) != len(tb.Rows) || tb.Column("x") != nil {
+				t.Errorf("Records/Column on empty table")
+			}
+		})
+	}
+	if c := parseTables(t, `<table><caption> Empty  table </caption></table>`)[0].Caption; c != "Empty table" {
+		t.Errorf("Caption = %q", c)
+	}
+}
+
+func TestTableCells(t *testing.T) {
+	tables := parseTables(t, `<table>
+		<tr><th>Name</th><th>Link</th></tr>
+		<tr><td rowspan="2" colspan="2"><a href="a.html">A</a> <a href=" /b ">B</a></td></tr>
+		<tr></tr>
+	</table>`)
+	rows := tables[0].Rows
+	if len(rows) != 2 {
+		t.Fatalf("got %s", tableSummary(tables[0]))
+	}
+	origin := rows[0][0]
+	if origin.Spanned || origin.RowSpan != 2 || origin.ColSpan != 2 {
+		t.Errorf("origin cell = %+v", origin)
+	}
+	if strings.Join(origin.Links, ",") != "http://example.com/list/a.html,http://example.com/b" {
+		t.Errorf("Links = %v", origin.Links)
+	}
+	for _, c := range []TableCell{rows[0][1], rows[1][0], rows[1][1]} {
+		if !c.Spanned || c.Text != "A B" {
+			t.Errorf("spanned cell = %+v", c)
+		}
+	}
+
+	zero := parseTables(t, `<table><tr><td rowspan="0">x</td></tr><tr><td>y</td></tr></table>`)[0]
+	if got := zero.Rows[0][0].RowSpan; got != 2 {
+		t.Errorf("rowspan=0 RowSpan = %d, want 2", got)
+	}
+}
+
+func TestTablesNested(t *testing.T) {
+	tables := parseTables(t, `<table><tr><th>Outer</th></tr><tr><td><table><tr><td>inner</td></tr></table></td></tr></table>`)
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(tables))
+	}
+	if got := tableSummary(tables[0]); got != "H: Outer\nR: inner" {
+		t.Errorf("outer = %s", got)
+	}
+	if got := tableSummary(tables[1]); got != "H: col1 (none)\nR: inner" {
+		t.Errorf("inner = %s", got)
+	}
+}
+
+func TestTableRecordsAndItems(t *testing.T) {
+	tb := parseTables(t, `<table><tr><th>Name</th><th>Age</th></tr><tr><td>a</td><td>1</td></tr><tr><td>b</td><td>2</td></tr></table>`)[0]
+
+	if got := strings.Join(tb.Column("Age"), ","); got != "1,2" {
+		t.Errorf("Column = %s", got)
+	}
+	records := tb.Records()
+	if len(records) != 2 || records[1]["Name"] != "b" || records[1]["Age"] != "2" {
+		t.Errorf("Records = %v", records)
+	}
+
+	items, err := tb.Items(map[string]string{"Name": "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := items[0].Get("name"); len(items) != 2 || v != "a" {
+		t.Errorf("Items = %v", items)
+	}
+	if _, ok := items[0].Get("Age"); ok {
+		t.Errorf("unmapped column kept")
+	}
+	if _, err := tb.Items(map[string]string{"Missing": "m"}); err == nil {
+		t.Errorf("Items with unknown column succeeded")
+	}
+}
@@ -0,0 +1,227 @@
+package item
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// namedProcessor 带名称的处理器，名称用于死信记录
+type namedProcessor struct {
+	name string
+	fn   func(*StrictItem) error
+}
+
+// processorName 通过反射取得处理函数名，匿名函数会得到类似 main.main.func1 的名称
+func processorName(fn func(*StrictItem) error) string {
+	if fn == nil {
+		return ""
+	}
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", fn)
+}
+
+// DeadLetter 处理失败的项目记录
+type DeadLetter struct {
+	Spider    string                 `json:"spider"`     // 产生项目的爬虫
+	Processor string                 `json:"processor"`  // 返回错误的处理器名称
+	Error     string                 `json:"error"`      // 错误信息
+	FailedAt  time.Time              `json:"failed_at"`  // 失败时间
+	FetchTime time.Time              `json:"fetch_time"` // 项目抓取时间
+	Fields    []string               `json:"fields"`     // 项目允许的字段
+	Data      map[string]interface{} `json:"data"`       // 项目数据
+}
+
+// NewDeadLetter 根据失败的项目创建死信记录
+func NewDeadLetter(item *StrictItem, processor string, err error) *DeadLetter {
+	letter := &DeadLetter{
+		Spider:    item.GetSpiderName(),
+		Processor: processor,
+		FailedAt:  time.Now(),
+		FetchTime: item.GetFetchTime(),
+		Fields:    item.GetAllowedFields(),
+		Data:      item.GetAll(),
+	}
+	if err != nil {
+		letter.Error = err.Error()
+	}
+	return letter
+}
+
+// Item 将死信还原为项目（保留爬虫名和抓取时间）。
+// 注意经过 JSON 往返后数字会变为 float64。
+func (d *DeadLetter) Item() *StrictItem {
+	it := NewStrictItem(d.Fields)
+	for k, v := range d.Data {
+		it.allowed[k] = struct{}{} // 兼容字段列表缺失的旧记录
+		it.data[k] = v
+	}
+	it.Metadata.SpiderName = d.Spider
+	if !d.FetchTime.IsZero() {
+		it.Metadata.FetchTime = d.FetchTime
+	}
+	return it
+}
+
+// DeadLetterSink 死信存储接口
+type DeadLetterSink interface {
+	Write(letter *DeadLetter) error
+	Close() error
+}
+
+// JSONLDeadLetterSink 将死信逐行写入 JSONL 文件
+type JSONLDeadLetterSink struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	enc    *json.Encoder
+	closed bool
+}
+
+// NewJSONLDeadLetterSink 创建死信存储。文件在首次写入时以追加模式打开（或创建），
+// 没有失败项目的运行不会留下空文件
+func NewJSONLDeadLetterSink(path string) (*JSONLDeadLetterSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("死信文件路径为空")
+	}
+	return &JSONLDeadLetterSink{path: path}, nil
+}
+
+// open 打开死信文件，调用方需持有锁
+func (s *JSONLDeadLetterSink) open() error {
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开死信文件失败: %w", err)
+	}
+	s.file = file
+	s.enc = json.NewEncoder(file)
+	return nil
+}
+
+// Write 写入一条死信，每条记录占一行
+func (s *JSONLDeadLetterSink) Write(letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	return s.enc.Encode(letter)
+}
+
+// Close 关闭死信文件
+func (s *JSONLDeadLetterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// ReadDeadLetters 读取 JSONL 死信文件中的全部记录
+func ReadDeadLetters(path string) ([]*DeadLetter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	letters := make([]*DeadLetter, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var letter DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return letters, fmt.Errorf("死信文件第 %d 行解析失败: %w", line, err)
+		}
+		letters = append(letters, &letter)
+	}
+	return letters, scanner.Err()
+}
+
+// SetDeadLetterSink 设置死信存储，处理器返回错误的项目会写入其中
+func (p *ItemPipeline) SetDeadLetterSink(sink DeadLetterSink) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deadLetter = sink
+}
+
+// writeDeadLetter 将失败项目写入死信存储
+func (p *ItemPipeline) writeDeadLetter(item *StrictItem, processor string, err error) {
+	p.mu.RLock()
+	sink := p.deadLetter
+	p.mu.RUnlock()
+	if sink == nil {
+		return
+	}
+
+	if werr := sink.Write(NewDeadLetter(item, processor, err)); werr != nil {
+		p.Logger.Errorf("写入死信失败: %v", werr)
+		return
+	}
+	p.Logger.Stats.AddInt("Item 死信", 1)
+}
+
+// ReplayDeadLetters 读取死信文件，将其中的项目重新交给处理器链处理。
+// 重放绕过验证和过滤，再次失败的项目会写入当前的死信存储，
+// 因此重放时应使用与源文件不同的死信存储。启用了去重时，重放的项目同样经过去重检查：
+// 相同项目已处理成功的被跳过（不计入 succeeded 与 failed），重放成功的项目会记录去重键。
+func (p *ItemPipeline) ReplayDeadLetters(path string) (succeeded int, failed int, err error) {
+	letters, err := ReadDeadLetters(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	p.mu.RLock()
+	deduper := p.deduper
+	p.mu.RUnlock()
+	for _, letter := range letters {
+		item := letter.Item()
+		if deduper != nil {
+			key, dup, err := deduper.Seen(item)
+			if err != nil {
+				p.Logger.Warnf("计算去重键失败，跳过去重: %v", err)
+			}
+			if dup {
+				atomic.AddInt64(&p.stats.TotalDuplicated, 1)
+				p.Logger.Stats.AddInt("Item 重复丢弃", 1)
+				continue
+			}
+			item.Metadata.dedupeKey = key
+		}
+		if perr := p.processItem(item); perr != nil {
+			failed++
+			continue
+		}
+		succeeded++
+	}
+	p.Logger.Stats.AddInt("Item 死信重放", succeeded)
+	return succeeded, failed, nil
+}
@@ -0,0 +1,176 @@
+package item
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DedupeConfig 去重配置
+type DedupeConfig struct {
+	Fields   []string                          // 参与计算去重键的字段，按顺序拼接
+	KeyFunc  func(*StrictItem) (string, error) // 自定义键函数，设置后优先于 Fields
+	FilePath string                            // 持久化文件路径，为空表示仅在内存中去重
+}
+
+// ItemDeduper 根据去重键过滤重复项目，可选地将已见过的键持久化到文件，
+// 使增量抓取只产出新项目。
+type ItemDeduper struct {
+	mu     sync.Mutex
+	config DedupeConfig
+	seen   map[string]struct{}
+	file   *os.File
+}
+
+// NewItemDeduper 创建去重器。如果配置了 FilePath，会先加载文件中已有的键。
+func NewItemDeduper(config DedupeConfig) (*ItemDeduper, error) {
+	if config.KeyFunc == nil && len(config.Fields) == 0 {
+		return nil, errors.New("去重配置需要 Fields 或 KeyFunc")
+	}
+
+	d := &ItemDeduper{
+		config: config,
+		seen:   make(map[string]struct{}),
+	}
+
+	if config.FilePath != "" {
+		if err := d.load(config.FilePath); err != nil {
+			return nil, fmt.Errorf("加载去重文件失败: %w", err)
+		}
+		if dir := filepath.Dir(config.FilePath); dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, err
+			}
+		}
+		file, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("打开去重文件失败: %w", err)
+		}
+		d.file = file
+	}
+
+	return d, nil
+}
+
+// load 从文件中读取已见过的键，每行一个。键按写入时的原样比较，不去除空白
+func (d *ItemDeduper) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key := scanner.Text(); key != "" {
+			d.seen[key] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// Key 计算项目的去重键，缺少任一去重字段时返回错误，
+// 避免缺少字段的项目得到相同的键而被误判为重复
+func (d *ItemDeduper) Key(item *StrictItem) (string, error) {
+	if d.config.KeyFunc != nil {
+		return d.config.KeyFunc(item)
+	}
+
+	h := sha1.New()
+	for _, field := range d.config.Fields {
+		val, ok := item.Get(field)
+		if !ok {
+			return "", fmt.Errorf("缺少去重字段 '%s'", field)
+		}
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return "", fmt.Errorf("字段 '%s' 无法序列化: %w", field, err)
+		}
+		h.Write([]byte(field))
+		h.Write([]byte{'='})
+		h.Write(encoded)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// storedKey 计算写入持久化文件的键。键中的换行会破坏文件的行格式，
+// 读取时行尾的 \r 也会被去掉，因此两者都替换为空格，保证读回的键与内存中的键一致
+func (d *ItemDeduper) storedKey(item *StrictItem) (string, error) {
+	key, err := d.Key(item)
+	if err != nil {
+		return "", err
+	}
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(key), nil
+}
+
+// Seen 检查项目是否已出现过。未出现过的键先记录在内存中，防止同一次运行中重复处理，
+// 并返回该键：项目处理成功后调用 Commit 写入持久化文件，失败时调用 Forget 移除，
+// 使失败的项目在之后（包括下一次增量运行）再次出现时可以重新处理。
+// 计算键失败（如缺少去重字段）时返回错误，调用方应视为新项目并跳过去重，避免误丢数据。
+func (d *ItemDeduper) Seen(item *StrictItem) (key string, dup bool, err error) {
+	key, err = d.storedKey(item)
+	if err != nil {
+		return "", false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[key]; ok {
+		return key, true, nil
+	}
+	d.seen[key] = struct{}{}
+	return key, false, nil
+}
+
+// Commit 记录键并写入持久化文件，在项目处理成功后调用
+func (d *ItemDeduper) Commit(key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seen[key] = struct{}{}
+	if d.file != nil {
+		if _, err := d.file.WriteString(key + "\n"); err != nil {
+			return fmt.Errorf("写入去重文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Forget 移除 Seen 记录的键，在项目处理失败后调用
+func (d *ItemDeduper) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, key)
+}
+
+// Size 返回已记录的键数量
+func (d *ItemDeduper) Size() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.seen)
+}
+
+// Close 关闭持久化文件
+func (d *ItemDeduper) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
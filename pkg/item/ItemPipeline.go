@@ -33,6 +33,7 @@ type PipelineStats struct {
 	TotalDequeued   int64         // 总出队数量
 	TotalProcessed  int64         // 总处理数量
 	TotalFailed     int64         // 失败数量
+	TotalDuplicated int64         // 去重丢弃数量
 	CurrentSize     int           // 当前大小
 	AvgProcessTime  time.Duration // 平均处理时间
 	LastProcessTime time.Time     // 最后处理时间
@@ -52,16 +53,20 @@ type ItemPipeline struct {
 	queue      *linkedlistqueue.Queue
 	mu         sync.RWMutex
 	cond       *sync.Cond
+	idle       *sync.Cond // inflight 归零时通知 Close
+	inflight   int        // ProcessNext 与 Flush 已出队但尚未处理完的项目数
 	config     PipelineConfig
 	stats      PipelineStats
 	callbacks  PipelineCallback
 	closed     bool
-	processor  func(*StrictItem) error   // 项目处理函数
-	processors []func(*StrictItem) error // 多个处理函数
+	processor  *namedProcessor           // 项目处理函数
+	processors []namedProcessor          // 多个处理函数
 	validators []func(*StrictItem) error // 验证器
 	filters    []func(*StrictItem) bool  // 过滤器
 	batchQueue *linkedlistqueue.Queue    // 批处理队列
 	batchSize  int
+	deduper    *ItemDeduper   // 去重器
+	deadLetter DeadLetterSink // 死信存储
 	*logger.Logger
 }
 
@@ -79,7 +84,7 @@ func NewItemPipeline(config PipelineConfig, logger *logger.Logger) *ItemPipeline
 		config:     config,
 		callbacks:  PipelineCallback{},
 		processor:  nil,
-		processors: make([]func(*StrictItem) error, 0),
+		processors: make([]namedProcessor, 0),
 		validators: make([]func(*StrictItem) error, 0),
 		filters:    make([]func(*StrictItem) bool, 0),
 		batchQueue: linkedlistqueue.New(),
@@ -87,6 +92,7 @@ func NewItemPipeline(config PipelineConfig, logger *logger.Logger) *ItemPipeline
 		Logger:     logger,
 	}
 	p.cond = sync.NewCond(&p.mu)
+	p.idle = sync.NewCond(&p.mu)
 
 	return p
 }
@@ -154,6 +160,20 @@ func (p *ItemPipeline) enqueueItem(item *StrictItem, blocking bool) error {
 		}
 	}
 
+	// 执行去重，键在项目处理成功后才持久化
+	if p.deduper != nil {
+		key, dup, err := p.deduper.Seen(item)
+		if err != nil {
+			p.Logger.Warnf("计算去重键失败，跳过去重: %v", err)
+		}
+		item.Metadata.dedupeKey = key
+		if dup {
+			atomic.AddInt64(&p.stats.TotalDuplicated, 1)
+			p.Logger.Stats.AddInt("Item 重复丢弃", 1)
+			return nil // 重复项目，不报错
+		}
+	}
+
 	// 入队
 	p.queue.Enqueue(item)
 	atomic.AddInt64(&p.stats.TotalEnqueued, 1)
@@ -177,16 +197,16 @@ func (p *ItemPipeline) enqueueItem(item *StrictItem, blocking bool) error {
 
 // DequeueItem 出队项目（阻塞式）
 func (p *ItemPipeline) DequeueItem() (*StrictItem, error) {
-	return p.dequeueItem(true)
+	return p.dequeueItem(true, false)
 }
 
 // TryDequeueItem 尝试出队项目（非阻塞）
 func (p *ItemPipeline) TryDequeueItem() (*StrictItem, error) {
-	return p.dequeueItem(false)
+	return p.dequeueItem(false, false)
 }
 
-// dequeueItem 内部出队实现
-func (p *ItemPipeline) dequeueItem(blocking bool) (*StrictItem, error) {
+// dequeueItem 内部出队实现，track 为 true 时计入 inflight，处理完后需调用 itemDone
+func (p *ItemPipeline) dequeueItem(blocking, track bool) (*StrictItem, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -224,6 +244,9 @@ func (p *ItemPipeline) dequeueItem(blocking bool) (*StrictItem, error) {
 
 	atomic.AddInt64(&p.stats.TotalDequeued, 1)
 	p.stats.CurrentSize = p.queue.Size()
+	if track {
+		p.inflight++
+	}
 
 	// 触发回调
 	if p.callbacks.OnItemDequeued != nil {
@@ -236,10 +259,20 @@ func (p *ItemPipeline) dequeueItem(blocking bool) (*StrictItem, error) {
 	return item, nil
 }
 
+// itemDone 标记一个计入 inflight 的项目处理完毕
+func (p *ItemPipeline) itemDone() {
+	p.mu.Lock()
+	p.inflight--
+	if p.inflight == 0 {
+		p.idle.Broadcast()
+	}
+	p.mu.Unlock()
+}
+
 // ProcessNext 处理下一个项目
 func (p *ItemPipeline) ProcessNext() error {
 	for {
-		item, err := p.DequeueItem()
+		item, err := p.dequeueItem(true, true)
 		if err != nil {
 			if errors.Is(err, ErrPipelineClosed) {
 				return nil
@@ -251,6 +284,7 @@ func (p *ItemPipeline) ProcessNext() error {
 		}
 
 		err = p.processItem(item)
+		p.itemDone()
 		if err != nil {
 			logger.Error("processItem", err)
 		}
@@ -261,15 +295,18 @@ func (p *ItemPipeline) ProcessNext() error {
 func (p *ItemPipeline) processItem(item *StrictItem) error {
 	startTime := time.Now()
 	var processErr error
+	var failedBy string
 
 	// 使用单个处理器
 	if p.processor != nil {
-		processErr = p.processor(item)
+		processErr = p.processor.fn(item)
+		failedBy = p.processor.name
 	} else if len(p.processors) > 0 {
 		// 使用多个处理器链
 		for _, processor := range p.processors {
-			if err := processor(item); err != nil {
+			if err := processor.fn(item); err != nil {
 				processErr = err
+				failedBy = processor.name
 				break
 			}
 		}
@@ -280,17 +317,21 @@ func (p *ItemPipeline) processItem(item *StrictItem) error {
 	atomic.AddInt64(&p.stats.TotalProcessed, 1)
 	if processErr != nil {
 		atomic.AddInt64(&p.stats.TotalFailed, 1)
+		p.writeDeadLetter(item, failedBy, processErr)
 	}
+	p.settleDedupe(item, processErr)
 
-	// 更新平均处理时间（简单移动平均）
+	// 更新平均处理时间（简单移动平均），ProcessNext 与 Flush 可能并发处理，需持有锁
+	p.mu.Lock()
 	oldAvg := p.stats.AvgProcessTime
-	count := p.stats.TotalProcessed
+	count := atomic.LoadInt64(&p.stats.TotalProcessed)
 	if count == 1 {
 		p.stats.AvgProcessTime = duration
 	} else {
 		p.stats.AvgProcessTime = (oldAvg*time.Duration(count-1) + duration) / time.Duration(count)
 	}
 	p.stats.LastProcessTime = time.Now()
+	p.mu.Unlock()
 
 	// 触发回调
 	if p.callbacks.OnItemProcessed != nil {
@@ -301,13 +342,31 @@ func (p *ItemPipeline) processItem(item *StrictItem) error {
 	return processErr
 }
 
+// settleDedupe 处理成功时持久化项目的去重键，失败时释放，使之后再次出现的项目可以重新处理
+func (p *ItemPipeline) settleDedupe(item *StrictItem, processErr error) {
+	p.mu.RLock()
+	deduper := p.deduper
+	p.mu.RUnlock()
+	key := item.Metadata.dedupeKey
+	if deduper == nil || key == "" {
+		return
+	}
+	if processErr != nil {
+		deduper.Forget(key)
+		return
+	}
+	if err := deduper.Commit(key); err != nil {
+		p.Logger.Warnf("%v", err)
+	}
+}
+
 // Flush 刷新所有项目
 func (p *ItemPipeline) Flush() error {
 	var lastErr error
 	count := 0
 
 	for {
-		item, err := p.TryDequeueItem()
+		item, err := p.dequeueItem(false, true)
 		if err != nil {
 			lastErr = err
 			break
@@ -319,6 +378,7 @@ func (p *ItemPipeline) Flush() error {
 		if err := p.processItem(item); err != nil {
 			lastErr = err
 		}
+		p.itemDone()
 		count++
 	}
 
@@ -354,21 +414,32 @@ func (p *ItemPipeline) BatchDequeue(count int) ([]*StrictItem, error) {
 	return items, nil
 }
 
-// Close 关闭管道
+// Close 关闭管道：不再接受新项目，处理完剩余项目并等待 ProcessNext 与自动刷新中
+// 正在处理的项目完成后返回，之后可以安全地关闭处理器使用的资源。重复调用直接返回
 func (p *ItemPipeline) Close() error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	if p.closed {
+		p.mu.Unlock()
 		return nil
 	}
-
 	p.closed = true
 	p.cond.Broadcast() // 唤醒所有等待的goroutine
+	p.mu.Unlock()
 
 	// 处理剩余项目
-	if !p.queue.Empty() {
-		go p.Flush()
+	p.Flush()
+
+	p.mu.Lock()
+	for p.inflight > 0 {
+		p.idle.Wait()
+	}
+	p.mu.Unlock()
+
+	// 所有项目处理完毕，可以关闭去重文件
+	if p.deduper != nil {
+		if err := p.deduper.Close(); err != nil {
+			p.Logger.Warnf("关闭去重文件失败: %v", err)
+		}
 	}
 
 	return nil
@@ -427,14 +498,23 @@ func (p *ItemPipeline) ResetStats() {
 func (p *ItemPipeline) SetProcessor(processor func(*StrictItem) error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.processor = processor
+	if processor == nil {
+		p.processor = nil
+		return
+	}
+	p.processor = &namedProcessor{name: processorName(processor), fn: processor}
 }
 
-// AddProcessor 添加处理器到链
+// AddProcessor 添加处理器到链，名称取自函数名
 func (p *ItemPipeline) AddProcessor(processor func(*StrictItem) error) {
+	p.AddNamedProcessor(processorName(processor), processor)
+}
+
+// AddNamedProcessor 添加带名称的处理器到链，名称会记录在死信中
+func (p *ItemPipeline) AddNamedProcessor(name string, processor func(*StrictItem) error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.processors = append(p.processors, processor)
+	p.processors = append(p.processors, namedProcessor{name: name, fn: processor})
 }
 
 // AddValidator 添加验证器
@@ -451,6 +531,23 @@ func (p *ItemPipeline) AddFilter(filter func(*StrictItem) bool) {
 	p.filters = append(p.filters, filter)
 }
 
+// SetDeduper 设置去重器，传入 nil 关闭去重
+func (p *ItemPipeline) SetDeduper(deduper *ItemDeduper) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.deduper = deduper
+}
+
+// EnableDedupe 根据配置创建去重器并启用去重
+func (p *ItemPipeline) EnableDedupe(config DedupeConfig) error {
+	deduper, err := NewItemDeduper(config)
+	if err != nil {
+		return err
+	}
+	p.SetDeduper(deduper)
+	return nil
+}
+
 // SetCallbacks 设置回调函数
 func (p *ItemPipeline) SetCallbacks(callbacks PipelineCallback) {
 	p.mu.Lock()
@@ -0,0 +1,300 @@
+package item
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldRule 单个字段的校验规则
+type FieldRule struct {
+	Type       string   `yaml:"type"`        // string / int / float / bool / list / map，为空表示不检查类型
+	Required   bool     `yaml:"required"`    // 是否必需
+	Regex      string   `yaml:"regex"`       // 字符串值需匹配的正则
+	Min        *float64 `yaml:"min"`         // 数值下限，字符串/列表时为长度下限
+	Max        *float64 `yaml:"max"`         // 数值上限，字符串/列表时为长度上限
+	Enum       []string `yaml:"enum"`        // 允许的取值（按字符串比较）
+	Format     string   `yaml:"format"`      // url / date
+	DateLayout string   `yaml:"date_layout"` // format 为 date 时的时间格式，默认 RFC3339
+}
+
+// Schema 声明式项目结构
+type Schema struct {
+	Fields map[string]FieldRule `yaml:"fields"`
+}
+
+// LoadSchema 从 YAML 文件加载结构声明
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("解析结构声明失败: %w", err)
+	}
+	return &schema, nil
+}
+
+// FieldError 字段级校验错误
+type FieldError struct {
+	Field   string // 字段名
+	Rule    string // 未通过的规则：required / type / regex / min / max / enum / format
+	Message string // 错误描述
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError 一个项目的全部字段错误
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return "字段校验失败: " + strings.Join(msgs, "; ")
+}
+
+// Fields 返回出错的字段名（去重）
+func (e *ValidationError) Fields() []string {
+	seen := make(map[string]struct{}, len(e.Errors))
+	fields := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		if _, ok := seen[fe.Field]; ok {
+			continue
+		}
+		seen[fe.Field] = struct{}{}
+		fields = append(fields, fe.Field)
+	}
+	return fields
+}
+
+// compiledRule 编译后的字段规则
+type compiledRule struct {
+	field string
+	rule  FieldRule
+	regex *regexp.Regexp
+	enum  map[string]struct{}
+}
+
+// CompiledSchema 编译后的结构声明，可并发使用
+type CompiledSchema struct {
+	rules []compiledRule
+}
+
+var schemaTypes = map[string]struct{}{
+	"": {}, "string": {}, "int": {}, "float": {}, "bool": {}, "list": {}, "map": {},
+}
+
+// Compile 编译结构声明，规则本身有误时返回错误
+func (s *Schema) Compile() (*CompiledSchema, error) {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names) // 保证错误报告顺序稳定
+
+	cs := &CompiledSchema{rules: make([]compiledRule, 0, len(names))}
+	for _, name := range names {
+		rule := s.Fields[name]
+		cr := compiledRule{field: name, rule: rule}
+
+		if _, ok := schemaTypes[rule.Type]; !ok {
+			return nil, fmt.Errorf("字段 '%s' 的类型 '%s' 不受支持", name, rule.Type)
+		}
+		switch rule.Format {
+		case "", "url", "date":
+		default:
+			return nil, fmt.Errorf("字段 '%s' 的格式 '%s' 不受支持", name, rule.Format)
+		}
+		if rule.Regex != "" {
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("字段 '%s' 的正则无效: %w", name, err)
+			}
+			cr.regex = re
+		}
+		if len(rule.Enum) > 0 {
+			cr.enum = make(map[string]struct{}, len(rule.Enum))
+			for _, v := range rule.Enum {
+				cr.enum[v] = struct{}{}
+			}
+		}
+		cs.rules = append(cs.rules, cr)
+	}
+	return cs, nil
+}
+
+// Validate 按结构声明校验项目，返回 *ValidationError 或 nil
+func (cs *CompiledSchema) Validate(item *StrictItem) error {
+	var errs []FieldError
+	for _, cr := range cs.rules {
+		val, ok := item.Get(cr.field)
+		if !ok || val == nil {
+			if cr.rule.Required {
+				errs = append(errs, FieldError{Field: cr.field, Rule: "required", Message: "缺少必需字段"})
+			}
+			continue
+		}
+		errs = append(errs, cr.check(val)...)
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// check 校验单个字段值
+func (cr *compiledRule) check(val interface{}) []FieldError {
+	var errs []FieldError
+	fail := func(rule, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: cr.field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	rv := reflect.ValueOf(val)
+	if !matchType(cr.rule.Type, rv) {
+		fail("type", "类型应为 %s，实际为 %T", cr.rule.Type, val)
+		return errs // 类型不符时其余规则没有意义
+	}
+
+	str, isString := val.(string)
+	if cr.regex != nil {
+		if !isString {
+			fail("regex", "正则规则只适用于字符串，实际为 %T", val)
+		} else if !cr.regex.MatchString(str) {
+			fail("regex", "值 %q 不匹配 %s", str, cr.rule.Regex)
+		}
+	}
+
+	if cr.rule.Min != nil || cr.rule.Max != nil {
+		if size, kind, ok := measure(rv); ok {
+			if cr.rule.Min != nil && size < *cr.rule.Min {
+				fail("min", "%s %v 小于 %v", kind, size, *cr.rule.Min)
+			}
+			if cr.rule.Max != nil && size > *cr.rule.Max {
+				fail("max", "%s %v 大于 %v", kind, size, *cr.rule.Max)
+			}
+		} else {
+			fail("min", "类型 %T 不支持范围校验", val)
+		}
+	}
+
+	if cr.enum != nil {
+		if _, ok := cr.enum[fmt.Sprintf("%v", val)]; !ok {
+			fail("enum", "值 %v 不在 %v 中", val, cr.rule.Enum)
+		}
+	}
+
+	switch cr.rule.Format {
+	case "url":
+		u, err := url.Parse(str)
+		if !isString || err != nil || u.Scheme == "" || u.Host == "" {
+			fail("format", "值 %v 不是有效的绝对 URL", val)
+		}
+	case "date":
+		if t, ok := val.(time.Time); ok && !t.IsZero() {
+			break
+		}
+		layout := cr.rule.DateLayout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		if _, err := time.Parse(layout, str); !isString || err != nil {
+			fail("format", "值 %v 不符合日期格式 %s", val, layout)
+		}
+	}
+	return errs
+}
+
+// matchType 检查值是否符合声明的类型
+func matchType(typ string, rv reflect.Value) bool {
+	switch typ {
+	case "":
+		return true
+	case "string":
+		return rv.Kind() == reflect.String
+	case "int":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			// JSON 解码后的整数是 float64
+			f := rv.Float()
+			return f == float64(int64(f))
+		}
+		return false
+	case "float":
+		switch rv.Kind() {
+		case reflect.Float32, reflect.Float64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case "bool":
+		return rv.Kind() == reflect.Bool
+	case "list":
+		return rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array
+	case "map":
+		return rv.Kind() == reflect.Map
+	}
+	return false
+}
+
+// measure 返回用于 min/max 比较的量：数值本身，或字符串/列表/映射的长度
+func measure(rv reflect.Value) (float64, string, bool) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), "值", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), "值", true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), "值", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(rv.String())), "长度", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(rv.Len()), "长度", true
+	}
+	return 0, "", false
+}
+
+// AddSchema 编译结构声明并注册为验证器，每个出错字段会计入统计
+// "Item 校验失败: <字段名>"
+func (p *ItemPipeline) AddSchema(schema *Schema) error {
+	cs, err := schema.Compile()
+	if err != nil {
+		return err
+	}
+
+	p.AddValidator(func(item *StrictItem) error {
+		err := cs.Validate(item)
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			for _, field := range verr.Fields() {
+				p.Logger.Stats.AddInt("Item 校验失败: "+field, 1)
+			}
+		}
+		return err
+	})
+	return nil
+}
+
+// Bound 用于在 Go 代码中声明 Min/Max，例如 FieldRule{Min: item.Bound(0)}
+func Bound(v float64) *float64 {
+	return &v
+}
@@ -18,6 +18,7 @@ type StrictItem struct {
 type metadata struct {
 	SpiderName string    // 哪个爬虫生成的
 	FetchTime  time.Time // 抓取时间
+	dedupeKey  string    // 入队时记录的去重键，处理成功后才持久化
 }
 
 // NewStrictItem 创建新的严格项
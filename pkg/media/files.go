@@ -0,0 +1,207 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+	"github.com/djskncxm/NewDuckSpider/pkg/item"
+	"github.com/djskncxm/NewDuckSpider/pkg/logger"
+)
+
+const (
+	StatusDownloaded = "downloaded" // 本次新下载
+	StatusUptodate   = "uptodate"   // 之前已下载，跳过
+)
+
+// Fetcher 下载接口。引擎实现了该接口：媒体下载经过同一个下载器的中间件、代理和重试，
+// 与爬虫的请求共用并发上限并遵守 Spider.Delay，但不进入调度队列
+type Fetcher interface {
+	Fetch(*httpc.Request) *httpc.Response
+}
+
+// Config 文件管道配置
+type Config struct {
+	URLField    string            // 项目中存放 URL 的字段，值可以是 string 或字符串列表
+	ResultField string            // 回写下载结果（[]FileResult）的字段，需在项目允许字段中
+	StoreDir    string            // 本地存储目录
+	Headers     map[string]string // 下载请求附加的请求头
+}
+
+// FileResult 单个文件的下载结果
+type FileResult struct {
+	URL      string `json:"url"`
+	Path     string `json:"path"`     // 相对存储目录的路径，按内容哈希命名
+	Checksum string `json:"checksum"` // 内容的 SHA-256
+	Status   string `json:"status"`   // downloaded / uptodate
+
+	// 以下字段仅由图片管道填写
+	Width  int               `json:"width,omitempty"`  // 原图宽度
+	Height int               `json:"height,omitempty"` // 原图高度
+	Thumbs map[string]string `json:"thumbs,omitempty"` // 缩略图名称 -> 相对路径
+}
+
+// FilesPipeline 下载项目中引用的文件，存储到本地并把结果写回项目
+type FilesPipeline struct {
+	fetcher Fetcher
+	store   Store
+	config  Config
+	logger  *logger.Logger
+	persist func(rawURL string, resp *httpc.Response) (FileResult, error) // 保存已下载的内容
+}
+
+// NewFilesPipeline 创建文件管道
+func NewFilesPipeline(fetcher Fetcher, store Store, config Config, log *logger.Logger) (*FilesPipeline, error) {
+	if fetcher == nil || store == nil {
+		return nil, errors.New("文件管道需要 Fetcher 和 Store")
+	}
+	if config.URLField == "" || config.ResultField == "" {
+		return nil, errors.New("文件管道需要 URLField 和 ResultField")
+	}
+	p := &FilesPipeline{
+		fetcher: fetcher,
+		store:   store,
+		config:  config,
+		logger:  log,
+	}
+	p.persist = p.persistFile
+	return p, nil
+}
+
+// Process 处理一个项目，可直接交给 ItemPipeline.AddProcessor。
+// 单个文件下载失败只记录日志和统计，不影响其他文件和后续处理器。
+func (p *FilesPipeline) Process(it *item.StrictItem) error {
+	urls := itemURLs(it, p.config.URLField)
+	results := make([]FileResult, 0, len(urls))
+
+	for _, rawURL := range urls {
+		if result, ok := p.store.Lookup(rawURL); ok {
+			result.Status = StatusUptodate
+			results = append(results, result)
+			p.logger.Stats.AddInt("Media 已存在", 1)
+			continue
+		}
+
+		result, err := p.download(rawURL)
+		if errors.Is(err, ErrImageTooSmall) {
+			p.logger.Debugf("图片尺寸过小，已丢弃 %s: %v", rawURL, err)
+			p.logger.Stats.AddInt("Media 图片过小", 1)
+			continue
+		}
+		if errors.Is(err, ErrImageTooLarge) {
+			p.logger.Warnf("图片尺寸过大，已丢弃 %s: %v", rawURL, err)
+			p.logger.Stats.AddInt("Media 图片过大", 1)
+			continue
+		}
+		if err != nil {
+			p.logger.Warnf("媒体下载失败 %s: %v", rawURL, err)
+			p.logger.Stats.AddInt("Media 下载失败", 1)
+			continue
+		}
+		if err := p.store.Record(result); err != nil {
+			p.logger.Warnf("记录媒体索引失败 %s: %v", rawURL, err)
+		}
+		results = append(results, result)
+		p.logger.Stats.AddInt("Media 下载完成", 1)
+	}
+
+	return it.Set(p.config.ResultField, results)
+}
+
+// download 通过 Fetcher 下载并保存单个文件
+func (p *FilesPipeline) download(rawURL string) (FileResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return FileResult{}, fmt.Errorf("无效的 URL")
+	}
+
+	req := httpc.New(rawURL).WithMeta("media", true)
+	for k, v := range p.config.Headers {
+		req.WithHeader(k, v)
+	}
+
+	resp := p.fetcher.Fetch(req)
+	if resp == nil {
+		return FileResult{}, errors.New("请求失败")
+	}
+	if resp.StatusCode >= 400 {
+		return FileResult{}, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	return p.persist(rawURL, resp)
+}
+
+// persistFile 按内容哈希保存原始文件
+func (p *FilesPipeline) persistFile(rawURL string, resp *httpc.Response) (FileResult, error) {
+	checksum := contentHash(resp.Body)
+	filePath := hashPath("full", checksum, fileExt(rawURL, resp.Headers["Content-Type"]))
+	if err := p.store.Persist(filePath, resp.Body); err != nil {
+		return FileResult{}, fmt.Errorf("保存文件失败: %w", err)
+	}
+	return FileResult{
+		URL:      rawURL,
+		Path:     filePath,
+		Checksum: checksum,
+		Status:   StatusDownloaded,
+	}, nil
+}
+
+// itemURLs 从项目字段中读取 URL 列表
+func itemURLs(it *item.StrictItem, field string) []string {
+	val, ok := it.Get(field)
+	if !ok {
+		return nil
+	}
+	switch v := val.(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		urls := make([]string, 0, len(v))
+		for _, u := range v {
+			if s, ok := u.(string); ok && s != "" {
+				urls = append(urls, s)
+			}
+		}
+		return urls
+	}
+	return nil
+}
+
+// contentHash 计算内容的 SHA-256
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPath 生成形如 full/ab/abcdef....jpg 的存储路径，前两位作为子目录避免单目录文件过多
+func hashPath(dir, hash, ext string) string {
+	return path.Join(dir, hash[:2], hash+ext)
+}
+
+// fileExt 优先取 URL 路径中的扩展名，否则根据 Content-Type 推断
+func fileExt(rawURL, contentType string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if ext != "" && len(ext) <= 6 {
+			return ext
+		}
+	}
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+				return exts[0]
+			}
+		}
+	}
+	return ""
+}
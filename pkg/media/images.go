@@ -0,0 +1,237 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码器
+	"image/jpeg"
+	"image/png"
+	"sort"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+	"github.com/djskncxm/NewDuckSpider/pkg/logger"
+)
+
+var (
+	// ErrImageTooSmall 表示图片小于配置的最小尺寸
+	ErrImageTooSmall = errors.New("image is too small")
+	// ErrImageTooLarge 表示图片像素数超过配置的上限
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// DefaultMaxPixels 默认的图片像素数上限（约 5000 万像素）。解码后每个像素至少占 4 字节，
+// 转码时还要再复制一份，很小的文件也可以声明极大的尺寸
+const DefaultMaxPixels = 50_000_000
+
+// ThumbSize 缩略图尺寸，图片会等比缩放到不超过该宽高
+type ThumbSize struct {
+	Width  int
+	Height int
+}
+
+// ImagesConfig 图片管道配置
+type ImagesConfig struct {
+	Config
+	MinWidth  int                  // 最小宽度，小于该值的图片被丢弃
+	MinHeight int                  // 最小高度，小于该值的图片被丢弃
+	MaxPixels int64                // 宽×高的上限，超过的图片不解码直接丢弃，默认 DefaultMaxPixels
+	Format    string               // 保存格式 jpeg / png，默认 jpeg
+	Quality   int                  // JPEG 质量，默认 90
+	Thumbs    map[string]ThumbSize // 缩略图名称 -> 尺寸
+}
+
+// ImagesPipeline 在文件管道基础上解码图片，过滤小图、统一格式并生成缩略图
+type ImagesPipeline struct {
+	*FilesPipeline
+	config ImagesConfig
+}
+
+// NewImagesPipeline 创建图片管道
+func NewImagesPipeline(fetcher Fetcher, store Store, config ImagesConfig, log *logger.Logger) (*ImagesPipeline, error) {
+	switch config.Format {
+	case "":
+		config.Format = FormatJPEG
+	case FormatJPEG, FormatPNG:
+	default:
+		return nil, fmt.Errorf("不支持的图片格式 '%s'", config.Format)
+	}
+	if config.MaxPixels <= 0 {
+		config.MaxPixels = DefaultMaxPixels
+	}
+	if config.Quality <= 0 || config.Quality > 100 {
+		config.Quality = 90
+	}
+	for name, size := range config.Thumbs {
+		if size.Width <= 0 || size.Height <= 0 {
+			return nil, fmt.Errorf("缩略图 '%s' 的尺寸无效", name)
+		}
+	}
+
+	files, err := NewFilesPipeline(fetcher, store, config.Config, log)
+	if err != nil {
+		return nil, err
+	}
+	p := &ImagesPipeline{
+		FilesPipeline: files,
+		config:        config,
+	}
+	files.persist = p.persistImage
+	return p, nil
+}
+
+// persistImage 先只读取图片头检查尺寸，通过后再完整解码，保存转换后的原图和缩略图
+func (p *ImagesPipeline) persistImage(rawURL string, resp *httpc.Response) (FileResult, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(resp.Body))
+	if err != nil {
+		return FileResult{}, fmt.Errorf("图片解码失败: %w", err)
+	}
+	width, height := cfg.Width, cfg.Height
+	if width < p.config.MinWidth || height < p.config.MinHeight {
+		return FileResult{}, fmt.Errorf("%w: %dx%d，要求至少 %dx%d",
+			ErrImageTooSmall, width, height, p.config.MinWidth, p.config.MinHeight)
+	}
+	if int64(width)*int64(height) > p.config.MaxPixels {
+		return FileResult{}, fmt.Errorf("%w: %dx%d，超过 %d 像素",
+			ErrImageTooLarge, width, height, p.config.MaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(resp.Body))
+	if err != nil {
+		return FileResult{}, fmt.Errorf("图片解码失败: %w", err)
+	}
+	bounds := img.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+
+	data, err := p.encode(img)
+	if err != nil {
+		return FileResult{}, err
+	}
+	checksum := contentHash(data)
+	ext := p.ext()
+	filePath := hashPath("full", checksum, ext)
+	if err := p.store.Persist(filePath, data); err != nil {
+		return FileResult{}, fmt.Errorf("保存图片失败: %w", err)
+	}
+
+	result := FileResult{
+		URL:      rawURL,
+		Path:     filePath,
+		Checksum: checksum,
+		Status:   StatusDownloaded,
+		Width:    width,
+		Height:   height,
+	}
+
+	if len(p.config.Thumbs) > 0 {
+		result.Thumbs = make(map[string]string, len(p.config.Thumbs))
+		names := make([]string, 0, len(p.config.Thumbs))
+		for name := range p.config.Thumbs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			thumb, err := p.encode(thumbnail(img, p.config.Thumbs[name]))
+			if err != nil {
+				return FileResult{}, fmt.Errorf("生成缩略图 '%s' 失败: %w", name, err)
+			}
+			// 缩略图以原图内容哈希命名，便于与原图对应
+			thumbPath := hashPath("thumbs/"+name, checksum, ext)
+			if err := p.store.Persist(thumbPath, thumb); err != nil {
+				return FileResult{}, fmt.Errorf("保存缩略图 '%s' 失败: %w", name, err)
+			}
+			result.Thumbs[name] = thumbPath
+		}
+	}
+	return result, nil
+}
+
+// ext 返回保存格式对应的扩展名
+func (p *ImagesPipeline) ext() string {
+	if p.config.Format == FormatPNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+// encode 按配置的格式编码图片，JPEG 不支持透明，透明部分以白色填充
+func (p *ImagesPipeline) encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if p.config.Format == FormatPNG {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("PNG 编码失败: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Over)
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: p.config.Quality}); err != nil {
+		return nil, fmt.Errorf("JPEG 编码失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// thumbnail 将图片等比缩放到不超过 size，不放大小图。
+// 使用区域平均采样，缩小时比最近邻更平滑。
+func thumbnail(img image.Image, size ThumbSize) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= size.Width && srcH <= size.Height {
+		return img
+	}
+
+	dstW, dstH := size.Width, srcH*size.Width/srcW
+	if dstH > size.Height {
+		dstW, dstH = srcW*size.Height/srcH, size.Height
+	}
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := bounds.Min.Y + (y+1)*srcH/dstH
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := bounds.Min.X + (x+1)*srcW/dstW
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,158 @@
+package media
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store 媒体文件存储接口
+type Store interface {
+	// Lookup 查找 URL 之前的下载结果，文件已不存在时返回 false
+	Lookup(url string) (FileResult, bool)
+	// Persist 将数据写入相对路径 path
+	Persist(path string, data []byte) error
+	// Record 记录 URL 的下载结果，供下次运行跳过
+	Record(result FileResult) error
+	// FullPath 返回相对路径对应的本地完整路径
+	FullPath(path string) string
+}
+
+// FSStore 本地文件系统存储，下载结果索引保存在根目录的 index.jsonl 中
+type FSStore struct {
+	root  string
+	mu    sync.Mutex
+	index map[string]FileResult
+	file  *os.File
+}
+
+// NewFSStore 创建本地存储并加载已有索引
+func NewFSStore(root string) (*FSStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("创建媒体目录失败: %w", err)
+	}
+
+	s := &FSStore{
+		root:  root,
+		index: make(map[string]FileResult),
+	}
+
+	indexPath := filepath.Join(root, "index.jsonl")
+	if err := s.load(indexPath); err != nil {
+		return nil, fmt.Errorf("加载媒体索引失败: %w", err)
+	}
+	file, err := os.OpenFile(indexPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开媒体索引失败: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+// load 读取索引，后出现的记录覆盖先出现的
+func (s *FSStore) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var result FileResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			continue // 跳过损坏的行
+		}
+		s.index[result.URL] = result
+	}
+	return scanner.Err()
+}
+
+// Lookup 查找 URL 之前的下载结果
+func (s *FSStore) Lookup(url string) (FileResult, bool) {
+	s.mu.Lock()
+	result, ok := s.index[url]
+	s.mu.Unlock()
+	if !ok {
+		return FileResult{}, false
+	}
+	if _, err := os.Stat(s.FullPath(result.Path)); err != nil {
+		return FileResult{}, false
+	}
+	return result, true
+}
+
+// Persist 写入文件，同内容的文件已存在时直接跳过
+func (s *FSStore) Persist(path string, data []byte) error {
+	full := s.FullPath(path)
+	if _, err := os.Stat(full); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return err
+	}
+	// 先写临时文件再重命名，避免中断时留下不完整的文件；临时文件名唯一，
+	// 并发写入同一路径时互不覆盖，重命名是原子的，最终内容相同
+	tmp, err := os.CreateTemp(filepath.Dir(full), filepath.Base(full)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Record 记录下载结果并追加到索引文件
+func (s *FSStore) Record(result FileResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index[result.URL] = result
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// FullPath 返回相对路径对应的本地完整路径
+func (s *FSStore) FullPath(path string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path))
+}
+
+// Close 关闭索引文件
+func (s *FSStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,171 @@
+package spider
+
+import (
+	"sync"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/extract"
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+)
+
+// Rule 声明式抓取规则：用 LinkExtractor 从页面中提取链接，交给 Callback 处理。
+// Follow 为 true 时继续在 Callback 处理的页面上应用规则；没有 Callback 的规则总是跟进
+type Rule struct {
+	LinkExtractor  *extract.LinkExtractor                               // 为 nil 时提取页面中全部链接
+	Callback       httpc.ParseFunc                                      // 处理提取到的页面
+	Follow         bool                                                 // 是否在提取到的页面上继续应用规则
+	ProcessLinks   func([]extract.Link) []extract.Link                  // 过滤或修改提取到的链接
+	ProcessRequest func(*httpc.Request, *httpc.Response) *httpc.Request // 修改生成的请求，返回 nil 表示丢弃
+}
+
+// follows 报告规则是否在匹配的页面上继续跟进
+func (r *Rule) follows() bool {
+	return r.Follow || r.Callback == nil
+}
+
+// CrawlSpider 按 Rules 自动跟进链接的爬虫，类似 Scrapy 的 CrawlSpider。
+// 起始页面先交给 Spider.Callback（可为空），然后依次应用所有规则；
+// 同一个链接只会被第一个提取到它的规则处理一次
+type CrawlSpider struct {
+	Spider
+	Rules []Rule
+
+	startCallback httpc.ParseFunc
+	visited       *urlSet // 已生成请求的 URL
+}
+
+// NewCrawlSpider 创建 CrawlSpider，sp.Callback（设置了 sp.Stream 时为 Stream）作为起始页面的回调，
+// 可直接注册返回值，引擎设置的日志记录器对规则回调可见
+func NewCrawlSpider(sp Spider, rules ...Rule) *CrawlSpider {
+	cs := &CrawlSpider{
+		Rules:         rules,
+		startCallback: sp.takeCallback(),
+		visited:       newURLSet(),
+	}
+	for i := range cs.Rules {
+		if cs.Rules[i].LinkExtractor == nil {
+			// 空配置不会编译失败
+			cs.Rules[i].LinkExtractor, _ = extract.NewLinkExtractor(extract.LinkExtractorConfig{})
+		}
+	}
+
+	sp.Callback = cs.parseStart
+	cs.Spider = sp
+	return cs
+}
+
+// parseStart 处理起始页面
+func (cs *CrawlSpider) parseStart(resp *httpc.Response) *httpc.ParseResult {
+	if resp == nil {
+		return nil
+	}
+	cs.visited.Add(resp.Request.URL)
+	cs.visited.Add(resp.URL)
+
+	result := cs.callback(cs.startCallback, resp)
+	result.Requests = append(result.Requests, cs.requestsToFollow(resp)...)
+	return result
+}
+
+// ruleCallback 返回规则 index 生成的请求所使用的回调
+func (cs *CrawlSpider) ruleCallback(index int) httpc.ParseFunc {
+	return func(resp *httpc.Response) *httpc.ParseResult {
+		if resp == nil {
+			return nil
+		}
+		rule := &cs.Rules[index]
+		result := cs.callback(rule.Callback, resp)
+		if rule.follows() {
+			result.Requests = append(result.Requests, cs.requestsToFollow(resp)...)
+		}
+		return result
+	}
+}
+
+// callback 执行用户回调，保证返回非 nil 的结果
+func (cs *CrawlSpider) callback(cb httpc.ParseFunc, resp *httpc.Response) *httpc.ParseResult {
+	if cb != nil {
+		if result := cb(resp); result != nil {
+			return result
+		}
+	}
+	return &httpc.ParseResult{}
+}
+
+// requestsToFollow 对页面应用全部规则，生成跟进请求
+func (cs *CrawlSpider) requestsToFollow(resp *httpc.Response) []*httpc.Request {
+	var requests []*httpc.Request
+	seen := make(map[string]struct{}) // 本页面内已被前面规则处理过的链接
+
+	for i := range cs.Rules {
+		rule := &cs.Rules[i]
+		links, err := rule.LinkExtractor.Extract(resp)
+		if err != nil {
+			cs.log().Errorf("规则 %d 提取链接失败 %s: %v", i, resp.URL, err)
+			continue
+		}
+
+		var fresh []extract.Link
+		for _, link := range links {
+			if _, ok := seen[link.URL]; ok {
+				continue
+			}
+			seen[link.URL] = struct{}{}
+			fresh = append(fresh, link)
+		}
+		if rule.ProcessLinks != nil {
+			fresh = rule.ProcessLinks(fresh)
+		}
+
+		for _, link := range fresh {
+			if cs.visited.Has(link.URL) {
+				continue
+			}
+			req, err := resp.Follow(link.URL, cs.ruleCallback(i))
+			if err != nil {
+				continue
+			}
+			req.WithMeta("rule", i).WithMeta("link_text", link.Text)
+			if rule.ProcessRequest != nil {
+				if req = rule.ProcessRequest(req, resp); req == nil {
+					continue
+				}
+			}
+			// 只有真正生成请求的链接才标记为已访问，被丢弃的链接之后仍可由其他页面或规则跟进；
+			// 其他 worker 可能已同时生成了同一链接的请求，Add 保证只生成一次
+			if !cs.visited.Add(link.URL) {
+				continue
+			}
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// urlSet 并发安全的 URL 集合，回调会在多个 worker 中同时执行
+type urlSet struct {
+	mu   sync.Mutex
+	urls map[string]struct{}
+}
+
+func newURLSet() *urlSet {
+	return &urlSet{urls: make(map[string]struct{})}
+}
+
+// Has 判断 URL 是否已记录
+func (s *urlSet) Has(u string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.urls[u]
+	return ok
+}
+
+// Add 记录 URL，首次出现时返回 true
+func (s *urlSet) Add(u string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.urls[u]; ok {
+		return false
+	}
+	s.urls[u] = struct{}{}
+	return true
+}
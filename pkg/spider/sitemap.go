@@ -0,0 +1,193 @@
+package spider
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/extract"
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+)
+
+// SitemapRule 将匹配 Pattern 的页面交给 Callback 处理
+type SitemapRule struct {
+	Pattern  string // URL 正则，为空表示匹配全部
+	Callback httpc.ParseFunc
+}
+
+// SitemapConfig 站点地图爬虫配置
+type SitemapConfig struct {
+	Rules          []SitemapRule                   // 按顺序匹配，第一个匹配的规则生效；为空时全部页面交给 Spider.Callback（或 Spider.Stream）
+	Follow         []string                        // 只跟进匹配这些正则的子站点地图，为空表示全部跟进
+	AlternateLinks bool                            // 同时抓取 hreflang 声明的其他语言版本
+	LastModAfter   time.Time                       // 只保留 lastmod 晚于该时间的记录，没有 lastmod 的记录保留
+	Filter         func(extract.SitemapEntry) bool // 自定义过滤，返回 false 丢弃
+}
+
+// compiledSitemapRule 编译后的规则
+type compiledSitemapRule struct {
+	re       *regexp.Regexp
+	callback httpc.ParseFunc
+}
+
+// SitemapSpider 从站点地图出发抓取页面，类似 Scrapy 的 SitemapSpider。
+// Spider.URL/URLs 可以是站点地图（支持 .xml.gz 和嵌套索引）或 robots.txt 地址，
+// robots.txt 中的 Sitemap: 声明会被自动跟进
+type SitemapSpider struct {
+	Spider
+	config SitemapConfig
+	rules  []compiledSitemapRule
+	follow []*regexp.Regexp
+	seen   *urlSet // 已生成请求的 URL（站点地图与页面）
+}
+
+// NewSitemapSpider 创建 SitemapSpider，规则中的正则无效时返回错误。
+// 可直接注册返回值，引擎设置的日志记录器对规则回调可见
+func NewSitemapSpider(sp Spider, config SitemapConfig) (*SitemapSpider, error) {
+	ss := &SitemapSpider{
+		config: config,
+		seen:   newURLSet(),
+	}
+
+	start := sp.takeCallback()
+	rules := config.Rules
+	if len(rules) == 0 {
+		rules = []SitemapRule{{Callback: start}}
+	}
+	for _, r := range rules {
+		re, err := httpc.CompileRegex(r.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		ss.rules = append(ss.rules, compiledSitemapRule{re: re, callback: r.Callback})
+	}
+	for _, p := range config.Follow {
+		re, err := httpc.CompileRegex(p)
+		if err != nil {
+			return nil, err
+		}
+		ss.follow = append(ss.follow, re)
+	}
+
+	sp.Callback = ss.parseSitemap
+	ss.Spider = sp
+	return ss, nil
+}
+
+// parseSitemap 处理 robots.txt 与站点地图响应
+func (ss *SitemapSpider) parseSitemap(resp *httpc.Response) *httpc.ParseResult {
+	if resp == nil {
+		return nil
+	}
+	result := &httpc.ParseResult{}
+
+	if isRobotsURL(resp.URL) {
+		for _, loc := range extract.SitemapsFromRobots(resp.Body) {
+			ss.followSitemap(resp, loc, result)
+		}
+		return result
+	}
+
+	sm, err := extract.ParseSitemap(resp.Body)
+	if err != nil {
+		ss.log().Errorf("站点地图解析失败 %s: %v", resp.URL, err)
+		return nil
+	}
+
+	for _, entry := range sm.Entries {
+		if !ss.keep(entry) {
+			continue
+		}
+		if sm.Type == extract.SitemapIndex {
+			if ss.shouldFollow(entry.Loc) {
+				ss.followSitemap(resp, entry.Loc, result)
+			}
+			continue
+		}
+
+		ss.enqueuePage(resp, entry.Loc, result)
+		if ss.config.AlternateLinks {
+			for _, alt := range entry.Alternates {
+				ss.enqueuePage(resp, alt.URL, result)
+			}
+		}
+	}
+	return result
+}
+
+// keep 按 lastmod 和自定义过滤器判断是否保留记录
+func (ss *SitemapSpider) keep(entry extract.SitemapEntry) bool {
+	if !ss.config.LastModAfter.IsZero() && !entry.LastMod.IsZero() && !entry.LastMod.After(ss.config.LastModAfter) {
+		return false
+	}
+	if ss.config.Filter != nil && !ss.config.Filter(entry) {
+		return false
+	}
+	return true
+}
+
+// shouldFollow 判断是否跟进子站点地图
+func (ss *SitemapSpider) shouldFollow(loc string) bool {
+	if len(ss.follow) == 0 {
+		return true
+	}
+	for _, re := range ss.follow {
+		if re.MatchString(loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// followSitemap 生成子站点地图请求
+func (ss *SitemapSpider) followSitemap(resp *httpc.Response, loc string, result *httpc.ParseResult) {
+	abs, ok := resolveLoc(resp.URL, loc)
+	if !ok || !ss.seen.Add(abs) {
+		return
+	}
+	result.Requests = append(result.Requests, httpc.New(abs).WithCallback(ss.parseSitemap))
+}
+
+// enqueuePage 按第一个匹配的规则生成页面请求
+func (ss *SitemapSpider) enqueuePage(resp *httpc.Response, loc string, result *httpc.ParseResult) {
+	for _, rule := range ss.rules {
+		if !rule.re.MatchString(loc) {
+			continue
+		}
+		abs, ok := resolveLoc(resp.URL, loc)
+		if !ok || !ss.seen.Add(abs) {
+			return
+		}
+		result.Requests = append(result.Requests, httpc.New(abs).WithCallback(rule.callback))
+		return
+	}
+}
+
+// resolveLoc 将站点地图中的地址解析为绝对 http(s) 地址。
+// 站点地图不是 HTML，不使用 Response.URLJoin 以免解析响应体
+func resolveLoc(base, loc string) (string, bool) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", false
+	}
+	ref, err := url.Parse(strings.TrimSpace(loc))
+	if err != nil {
+		return "", false
+	}
+	u := b.ResolveReference(ref)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isRobotsURL 判断是否为 robots.txt 地址
+func isRobotsURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(path.Base(u.Path), "robots.txt")
+}
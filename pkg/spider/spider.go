@@ -1,6 +1,8 @@
 package spider
 
 import (
+	"iter"
+
 	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
 	"github.com/djskncxm/NewDuckSpider/pkg/logger"
 )
@@ -10,12 +12,61 @@ type SpiderIns interface {
 	Start() []*httpc.Request
 }
 
+// 爬虫关闭原因，传给 Closer.Close
+const (
+	CloseFinished   = "finished"    // 所有请求处理完毕
+	CloseOpenFailed = "open_failed" // Opener.Open 返回错误，爬虫没有启动
+)
+
+// Opener 爬虫启动前调用，用于打开数据库连接等资源，返回错误时爬虫不会启动
+type Opener interface {
+	Open() error
+}
+
+// Closer 爬虫结束、项目管道中的全部项目处理完毕后调用，此时处理器不会再使用爬虫的资源，
+// reason 为关闭原因（CloseFinished 等）。Open 失败时也会调用，以便释放已经打开的部分资源
+type Closer interface {
+	Close(reason string) error
+}
+
+// CustomSettings 爬虫专属配置，键值覆盖共享配置中的同名键（如 "Spider.Worker": "8"），
+// 只作用于该爬虫
+type CustomSettings interface {
+	CustomSettings() map[string]string
+}
+
+// LoggerSetter 接收引擎为爬虫创建的日志记录器
+type LoggerSetter interface {
+	SetLogger(l *logger.Logger)
+}
+
+// StartStreamer 按需产生初始请求的爬虫。引擎优先使用 StartRequests 而不是 Start，
+// 在调度队列有空位时逐个取出请求，种子列表不需要一次性载入内存
+type StartStreamer interface {
+	StartRequests() iter.Seq[*httpc.Request]
+}
+
 type Spider struct {
 	SpiderName string
 	URL        string
 	URLs       []string
-	Callback   func(*httpc.Response) *httpc.ParseResult // Request | Iiem 使用chan进行动态流处理item
+	Callback   func(*httpc.Response) *httpc.ParseResult // Request | Item，需要边解析边产生结果时使用 Stream
+	Stream     httpc.StreamFunc                         // 流式回调，设置后初始请求优先使用 Stream
 	Logger     *logger.Logger
+	Starts     iter.Seq[*httpc.Request] // 按需产生的初始请求（如 URLFile、CSVFile），在 URL、URLs 之后产生
+}
+
+// SetLogger 设置日志记录器，以指针注册爬虫时由引擎调用；以值注册时引擎直接设置副本的 Logger
+func (s *Spider) SetLogger(l *logger.Logger) {
+	s.Logger = l
+}
+
+// log 返回引擎设置的日志记录器，爬虫未经引擎运行时使用默认日志记录器
+func (s *Spider) log() *logger.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return logger.GetDefaultLogger()
 }
 
 func (s Spider) Name() string {
@@ -27,12 +78,45 @@ func (s Spider) Start() []*httpc.Request {
 
 	if s.URL != "" {
 		// 初始请求绑定 Spider.Callback
-		res = append(res, httpc.New(s.URL).WithCallback(s.Callback))
+		res = append(res, httpc.New(s.URL).WithCallback(s.Callback).WithStream(s.Stream))
 	}
 
 	for _, u := range s.URLs {
-		res = append(res, httpc.New(u).WithCallback(s.Callback))
+		res = append(res, httpc.New(u).WithCallback(s.Callback).WithStream(s.Stream))
 	}
 
 	return res
 }
+
+// takeCallback 返回起始页面的回调并清空 Stream，设置了 Stream 时通过 httpc.Collect 转换。
+// 派生爬虫（CrawlSpider 等）替换 Callback 前调用，否则初始请求会优先使用 Stream 而绕过派生爬虫的逻辑
+func (s *Spider) takeCallback() httpc.ParseFunc {
+	cb := s.Callback
+	if s.Stream != nil {
+		cb = httpc.Collect(s.Stream)
+		s.Stream = nil
+	}
+	return cb
+}
+
+// StartRequests 依次产生 URL、URLs 与 Starts 中的初始请求，没有回调的请求绑定 Spider.Callback 与 Spider.Stream
+func (s Spider) StartRequests() iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		for _, req := range s.Start() {
+			if !yield(req) {
+				return
+			}
+		}
+		if s.Starts == nil {
+			return
+		}
+		for req := range s.Starts {
+			if req.Callback == nil && req.Stream == nil {
+				req.Callback, req.Stream = s.Callback, s.Stream
+			}
+			if !yield(req) {
+				return
+			}
+		}
+	}
+}
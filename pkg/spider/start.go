@@ -0,0 +1,210 @@
+package spider
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+	"github.com/djskncxm/NewDuckSpider/pkg/logger"
+)
+
+// maxURLLineSize 单行 URL 的最大长度
+const maxURLLineSize = 1 << 20
+
+// seedRequest 为初始 URL 创建 GET 请求，URL 无法解析或缺少协议、主机时记录警告并返回 nil，
+// 避免一行坏数据在下载时中断整个抓取
+func seedRequest(raw string) *httpc.Request {
+	u, err := url.Parse(raw)
+	if err != nil {
+		logger.Warnf("跳过无效的初始 URL %q: %v", raw, err)
+		return nil
+	}
+	if u.Scheme == "" || u.Host == "" {
+		logger.Warnf("跳过无效的初始 URL %q: 缺少协议或主机", raw)
+		return nil
+	}
+	return httpc.New(raw)
+}
+
+// URLLines 逐行读取 r 中的 URL 并产生 GET 请求，去除首尾空白，跳过空行与 # 开头的注释行，
+// 无效的 URL 记录警告后跳过。读取失败时记录错误并结束迭代
+func URLLines(r io.Reader) iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxURLLineSize)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			req := seedRequest(line)
+			if req == nil {
+				continue
+			}
+			if !yield(req) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			logger.Errorf("读取初始 URL 失败: %v", err)
+		}
+	}
+}
+
+// URLFile 逐行读取文件中的 URL，规则同 URLLines，.gz 结尾的文件按 gzip 解压。
+// 文件在开始迭代时打开、结束时关闭，打开失败时记录错误并结束迭代
+func URLFile(path string) iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		r, closeFn, err := openSource(path)
+		if err != nil {
+			logger.Errorf("打开初始 URL 文件失败: %v", err)
+			return
+		}
+		defer closeFn()
+		for req := range URLLines(r) {
+			if !yield(req) {
+				return
+			}
+		}
+	}
+}
+
+// Stdin 从标准输入逐行读取 URL，规则同 URLLines
+func Stdin() iter.Seq[*httpc.Request] {
+	return URLLines(os.Stdin)
+}
+
+// CSVConfig CSV 初始请求的读取方式
+type CSVConfig struct {
+	Column   string // URL 所在列的列名（第一行为表头）
+	Index    int    // NoHeader 为 true 时 URL 所在列的下标
+	NoHeader bool   // 第一行不是表头
+	Comma    rune   // 分隔符，默认 ','
+	Meta     bool   // 将同一行的其他列以列名为键写入 Meta（需要表头）
+}
+
+// CSVReader 从 CSV 的指定列读取 URL 并产生 GET 请求，跳过 URL 为空的行，无效的 URL 记录警告后跳过。
+// 表头中找不到列或读取失败时记录错误并结束迭代
+func CSVReader(r io.Reader, config CSVConfig) iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.ReuseRecord = true
+		if config.Comma != 0 {
+			reader.Comma = config.Comma
+		}
+
+		column := config.Index
+		var header []string
+		if !config.NoHeader {
+			record, err := reader.Read()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					logger.Errorf("读取 CSV 表头失败: %v", err)
+				}
+				return
+			}
+			header = append([]string(nil), record...)
+			column = -1
+			for i, name := range header {
+				if strings.TrimSpace(name) == config.Column {
+					column = i
+					break
+				}
+			}
+			if column < 0 {
+				logger.Errorf("CSV 表头中没有列 %q", config.Column)
+				return
+			}
+		}
+
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				logger.Errorf("读取 CSV 失败: %v", err)
+				return
+			}
+			if column >= len(record) {
+				continue
+			}
+			u := strings.TrimSpace(record[column])
+			if u == "" {
+				continue
+			}
+			req := seedRequest(u)
+			if req == nil {
+				continue
+			}
+			if config.Meta && header != nil {
+				for i, value := range record {
+					if i != column && i < len(header) {
+						req.Meta[header[i]] = value
+					}
+				}
+			}
+			if !yield(req) {
+				return
+			}
+		}
+	}
+}
+
+// CSVFile 从 CSV 文件读取初始请求，规则同 CSVReader，.gz 结尾的文件按 gzip 解压
+func CSVFile(path string, config CSVConfig) iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		r, closeFn, err := openSource(path)
+		if err != nil {
+			logger.Errorf("打开 CSV 文件失败: %v", err)
+			return
+		}
+		defer closeFn()
+		for req := range CSVReader(r, config) {
+			if !yield(req) {
+				return
+			}
+		}
+	}
+}
+
+// Chain 依次产生多个序列中的请求
+func Chain(seqs ...iter.Seq[*httpc.Request]) iter.Seq[*httpc.Request] {
+	return func(yield func(*httpc.Request) bool) {
+		for _, seq := range seqs {
+			for req := range seq {
+				if !yield(req) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// openSource 打开文件，.gz 结尾时套上 gzip 解压
+func openSource(path string) (io.Reader, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return f, func() { f.Close() }, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return gz, func() {
+		gz.Close()
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,60 @@
+package spider
+
+import (
+	"github.com/djskncxm/NewDuckSpider/pkg/httpc"
+)
+
+// NodeFunc 处理订阅源中的一个节点
+type NodeFunc func(*httpc.Response, *httpc.Selector) *httpc.ParseResult
+
+// XMLFeedConfig XML 订阅源爬虫配置
+type XMLFeedConfig struct {
+	ItemTag    string            // 迭代的节点名，可带前缀（如 "item"、"atom:entry"），默认 item
+	Namespaces map[string]string // 额外注册的命名空间前缀，文档中声明的前缀会自动注册
+	ParseNode  NodeFunc          // 处理每个节点，必填
+}
+
+// XMLFeedSpider 按节点名遍历 XML 订阅源的爬虫，类似 Scrapy 的 XMLFeedSpider。
+// 响应总是按 XML 解析，不依赖 Content-Type
+type XMLFeedSpider struct {
+	Spider
+	config XMLFeedConfig
+}
+
+// NewXMLFeedSpider 创建 XMLFeedSpider，Spider.Callback 会被替换为节点遍历逻辑，Spider.Stream 被忽略。
+// 可直接注册返回值，引擎设置的日志记录器对规则回调可见
+func NewXMLFeedSpider(sp Spider, config XMLFeedConfig) *XMLFeedSpider {
+	if config.ItemTag == "" {
+		config.ItemTag = "item"
+	}
+	fs := &XMLFeedSpider{config: config}
+	sp.takeCallback()
+	sp.Callback = fs.parseFeed
+	fs.Spider = sp
+	return fs
+}
+
+// parseFeed 遍历节点并合并 ParseNode 的结果
+func (fs *XMLFeedSpider) parseFeed(resp *httpc.Response) *httpc.ParseResult {
+	if resp == nil || fs.config.ParseNode == nil {
+		return nil
+	}
+	for prefix, uri := range fs.config.Namespaces {
+		resp.RegisterNamespace(prefix, uri)
+	}
+
+	nodes := resp.XMLSelector().XPath("//" + fs.config.ItemTag)
+	if err := nodes.Error(); err != nil {
+		fs.log().Errorf("订阅源解析失败 %s: %v", resp.URL, err)
+		return nil
+	}
+
+	result := &httpc.ParseResult{}
+	for _, node := range nodes.All() {
+		if r := fs.config.ParseNode(resp, node); r != nil {
+			result.Requests = append(result.Requests, r.Requests...)
+			result.Items = append(result.Items, r.Items...)
+		}
+	}
+	return result
+}